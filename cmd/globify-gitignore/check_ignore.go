@@ -0,0 +1,360 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aminya/globify-gitignore/lib"
+)
+
+const checkIgnoreUsage = `Usage: globify-gitignore check-ignore [flags] pathname...
+   or: globify-gitignore check-ignore [flags] --stdin
+
+Prints the given paths that are ignored, like git check-ignore --no-index. The
+ignore files are read from the enclosing directory that has a .git (or the
+current directory), its .git/info/exclude, and the .gitignore files of the
+parents of the paths. Like git, the paths are compared case-insensitively if
+core.ignoreCase is set in the config of the repository, and the decomposed
+Unicode names match the composed patterns if core.precomposeUnicode is set. The
+global excludes file of git is not read.
+
+The exit status is 0 if some paths are ignored, 1 if none is, and 128 for the
+errors.
+
+Flags:
+`
+
+/** The exit statuses of check-ignore (the same as git check-ignore) */
+const (
+	checkIgnoreNoMatch = 1
+	checkIgnoreFatal   = 128
+	checkIgnoreBadFlag = 129
+)
+
+func runCheckIgnore(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("check-ignore", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	var verbose, nonMatching, quiet, readStdin, nulTerminated, noIndex bool
+	flags.BoolVar(&verbose, "v", false, "print the matching pattern of each path")
+	flags.BoolVar(&verbose, "verbose", false, "the same as -v")
+	flags.BoolVar(&nonMatching, "n", false, "print the paths that do not match a pattern too (with -v)")
+	flags.BoolVar(&nonMatching, "non-matching", false, "the same as -n")
+	flags.BoolVar(&quiet, "q", false, "print nothing, only set the exit status (with a single path)")
+	flags.BoolVar(&quiet, "quiet", false, "the same as -q")
+	flags.BoolVar(&readStdin, "stdin", false, "read the paths from the standard input, one per line")
+	flags.BoolVar(&nulTerminated, "z", false, "the paths and the output fields are separated by NUL (with --stdin)")
+	flags.BoolVar(&noIndex, "no-index", false, "accepted for compatibility. The index is never read.")
+	flags.Usage = func() {
+		fmt.Fprint(stderr, checkIgnoreUsage)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return checkIgnoreBadFlag
+	}
+
+	pathnames := flags.Args()
+	switch {
+	case readStdin && len(pathnames) != 0:
+		return fatal(stderr, errors.New("cannot specify pathnames with --stdin"))
+	case nulTerminated && !readStdin:
+		return fatal(stderr, errors.New("-z only makes sense with --stdin"))
+	case !readStdin && len(pathnames) == 0:
+		return fatal(stderr, errors.New("no path specified"))
+	case quiet && verbose:
+		return fatal(stderr, errors.New("cannot have both --quiet and --verbose"))
+	case quiet && len(pathnames) != 1:
+		return fatal(stderr, errors.New("--quiet is only valid with a single pathname"))
+	case nonMatching && !verbose:
+		return fatal(stderr, errors.New("--non-matching is only valid with --verbose"))
+	}
+
+	checker, err := newIgnoreChecker()
+	if err != nil {
+		return fatal(stderr, err)
+	}
+	output := ignoreOutput{stdout: stdout, verbose: verbose, nonMatching: nonMatching, nulTerminated: nulTerminated}
+	if quiet {
+		output.stdout = io.Discard
+	}
+
+	ignored := 0
+	check := func(pathname string) error {
+		pattern, err := checker.check(pathname)
+		if err != nil {
+			return err
+		}
+		if pattern != nil && pattern.Negated && !verbose {
+			pattern = nil
+		}
+		if pattern != nil {
+			ignored++
+		}
+		return output.write(pathname, pattern)
+	}
+
+	if readStdin {
+		err = readPathnames(stdin, nulTerminated, check)
+	} else {
+		for _, pathname := range pathnames {
+			if err = check(pathname); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return fatal(stderr, err)
+	}
+	if ignored == 0 {
+		return checkIgnoreNoMatch
+	}
+	return exitOK
+}
+
+/** Prints a fatal error like git and returns its exit status */
+func fatal(stderr io.Writer, err error) int {
+	fmt.Fprintf(stderr, "fatal: %v\n", err)
+	return checkIgnoreFatal
+}
+
+/** Finds the patterns that match the paths given relative to the working directory */
+type ignoreChecker struct {
+	workingDirectory string
+	root             string
+	tree             *lib.IgnoreTree
+}
+
+func newIgnoreChecker() (*ignoreChecker, error) {
+	workingDirectory, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	root := findRoot(workingDirectory)
+
+	excludeFile := filepath.Join(root, ".git", "info", "exclude")
+	content, err := os.ReadFile(excludeFile)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	excludes := lib.ParseGitIgnore(string(content))
+	for iPattern := range excludes {
+		excludes[iPattern].Source = ".git/info/exclude"
+	}
+	options, err := repositoryWalkOptions(root, excludes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ignoreChecker{
+		workingDirectory: workingDirectory,
+		root:             root,
+		tree:             lib.NewIgnoreTree(os.DirFS(root), ".", options),
+	}, nil
+}
+
+/**
+ * The options of matching the paths of a repository like git: the excludes apply first, the paths are compared
+ * case-insensitively if core.ignoreCase is set, and they are precomposed if core.precomposeUnicode is set. Outside of
+ * the repositories, both are false.
+ */
+func repositoryWalkOptions(repository string, excludes []lib.Pattern) (lib.WalkOptions, error) {
+	options := lib.WalkOptions{Matcher: lib.NewMatcher(excludes)}
+	ignoreCase, _, err := lib.ReadGitIgnoreCase(filepath.Join(repository, ".git"))
+	if err != nil {
+		return options, err
+	}
+	options.IgnoreCase = ignoreCase
+	options.Normalization, err = lib.DetectNormalization(repository)
+	return options, err
+}
+
+/** The closest directory that has a `.git`, or the directory itself if there is none */
+func findRoot(directory string) string {
+	for current := directory; ; {
+		if _, err := os.Lstat(filepath.Join(current, ".git")); err == nil {
+			return current
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return directory
+		}
+		current = parent
+	}
+}
+
+/**
+ * Get the pattern that decides whether the path is ignored. A trailing slash or an existing directory makes the path a
+ * directory.
+ */
+func (checker *ignoreChecker) check(pathname string) (*lib.Pattern, error) {
+	absolute := pathname
+	if !filepath.IsAbs(absolute) {
+		absolute = filepath.Join(checker.workingDirectory, pathname)
+	}
+	relative, err := filepath.Rel(checker.root, absolute)
+	if err != nil || relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("%s: '%s' is outside repository at '%s'", pathname, pathname, checker.root)
+	}
+	if relative == "." {
+		return nil, nil
+	}
+
+	isDir := strings.HasSuffix(pathname, "/")
+	if info, err := os.Lstat(absolute); err == nil && info.IsDir() {
+		isDir = true
+	}
+	return checker.tree.LastMatchingPattern(filepath.ToSlash(relative), isDir)
+}
+
+/** Writes the results in the format of git check-ignore */
+type ignoreOutput struct {
+	stdout        io.Writer
+	verbose       bool
+	nonMatching   bool
+	nulTerminated bool
+}
+
+/**
+ * Writes the result of a path
+ *
+ * @param {string} pathname The path as given
+ * @param {*lib.Pattern} pattern The matching pattern or nil
+ */
+func (output ignoreOutput) write(pathname string, pattern *lib.Pattern) error {
+	if pattern == nil && !output.nonMatching {
+		return nil
+	}
+	var err error
+	switch {
+	case !output.verbose && output.nulTerminated:
+		_, err = fmt.Fprintf(output.stdout, "%s\x00", pathname)
+	case !output.verbose:
+		_, err = fmt.Fprintf(output.stdout, "%s\n", quotePath(pathname))
+	case output.nulTerminated && pattern == nil:
+		_, err = fmt.Fprintf(output.stdout, "\x00\x00\x00%s\x00", pathname)
+	case output.nulTerminated:
+		_, err = fmt.Fprintf(output.stdout, "%s\x00%d\x00%s\x00%s\x00", pattern.Source, pattern.Line, pattern, pathname)
+	case pattern == nil:
+		_, err = fmt.Fprintf(output.stdout, "::\t%s\n", quotePath(pathname))
+	default:
+		_, err = fmt.Fprintf(output.stdout, "%s:%d:%s\t%s\n", quotePath(pattern.Source), pattern.Line, pattern, quotePath(pathname))
+	}
+	return err
+}
+
+/** Calls fn for each path of the input. The lines that start with a double quote are unquoted like git. */
+func readPathnames(input io.Reader, nulTerminated bool, fn func(pathname string) error) error {
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(nil, 1<<20)
+	if nulTerminated {
+		scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+			if index := bytes.IndexByte(data, 0); index != -1 {
+				return index + 1, data[:index], nil
+			}
+			if atEOF && len(data) != 0 {
+				return len(data), data, nil
+			}
+			return 0, nil, nil
+		})
+	}
+	for scanner.Scan() {
+		pathname := scanner.Text()
+		if !nulTerminated && strings.HasPrefix(pathname, "\"") {
+			unquoted, err := unquotePath(pathname)
+			if err != nil {
+				return err
+			}
+			pathname = unquoted
+		}
+		if err := fn(pathname); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+/** The C escapes of git */
+const quoteEscapes = "\a\b\t\n\v\f\r\"\\"
+const quoteLetters = "abtnvfr\"\\"
+
+/** Quotes a path like git with `core.quotePath` (the control characters, `"`, `\` and the non-ASCII bytes) */
+func quotePath(pathname string) string {
+	needsQuotes := false
+	for i := 0; i < len(pathname); i++ {
+		if ch := pathname[i]; ch < 0x20 || ch >= 0x7f || ch == '"' || ch == '\\' {
+			needsQuotes = true
+			break
+		}
+	}
+	if !needsQuotes {
+		return pathname
+	}
+
+	var builder strings.Builder
+	builder.WriteByte('"')
+	for i := 0; i < len(pathname); i++ {
+		ch := pathname[i]
+		if index := strings.IndexByte(quoteEscapes, ch); index != -1 {
+			builder.WriteByte('\\')
+			builder.WriteByte(quoteLetters[index])
+		} else if ch < 0x20 || ch >= 0x7f {
+			fmt.Fprintf(&builder, "\\%03o", ch)
+		} else {
+			builder.WriteByte(ch)
+		}
+	}
+	builder.WriteByte('"')
+	return builder.String()
+}
+
+/** Reverses `quotePath` */
+func unquotePath(quoted string) (string, error) {
+	badlyQuoted := fmt.Errorf("line is badly quoted: %s", quoted)
+	if len(quoted) < 2 || quoted[len(quoted)-1] != '"' {
+		return "", badlyQuoted
+	}
+	quoted = quoted[1 : len(quoted)-1]
+
+	var builder strings.Builder
+	for i := 0; i < len(quoted); i++ {
+		ch := quoted[i]
+		if ch == '"' {
+			return "", badlyQuoted
+		}
+		if ch != '\\' {
+			builder.WriteByte(ch)
+			continue
+		}
+		i++
+		if i == len(quoted) {
+			return "", badlyQuoted
+		}
+		if index := strings.IndexByte(quoteLetters, quoted[i]); index != -1 {
+			builder.WriteByte(quoteEscapes[index])
+			continue
+		}
+		// an octal byte
+		if i+3 > len(quoted) || quoted[i] < '0' || quoted[i] > '3' {
+			return "", badlyQuoted
+		}
+		value := 0
+		for _, digit := range []byte(quoted[i : i+3]) {
+			if digit < '0' || digit > '7' {
+				return "", badlyQuoted
+			}
+			value = value*8 + int(digit-'0')
+		}
+		builder.WriteByte(byte(value))
+		i += 2
+	}
+	return builder.String(), nil
+}
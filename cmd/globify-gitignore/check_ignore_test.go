@@ -0,0 +1,151 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/** Creates a tree with ignore files and changes the working directory to it */
+func checkIgnoreTree(t *testing.T) string {
+	root := t.TempDir()
+	files := map[string]string{
+		".git/info/exclude": "*.tmp\n",
+		".gitignore":        "foo/\n/baz\n*.log\n!keep.log\n",
+		"sub/.gitignore":    "x\n",
+		"foo/a":             "",
+	}
+	writeFiles(t, root, files)
+	chdir(t, root)
+	return root
+}
+
+func TestCheckIgnore(t *testing.T) {
+	checkIgnoreTree(t)
+
+	status, stdout, stderr := runCommand("", "check-ignore", "x.log", "keep.log", "main.go", "foo", "foo/a", "baz/", "a.tmp")
+	assert.Equal(t, status, exitOK)
+	assert.Equal(t, stderr, "")
+	assert.Equal(t, stdout, "x.log\nfoo\nfoo/a\nbaz/\na.tmp\n")
+
+	status, stdout, _ = runCommand("", "check-ignore", "main.go", "keep.log")
+	assert.Equal(t, status, checkIgnoreNoMatch)
+	assert.Equal(t, stdout, "")
+
+	status, stdout, _ = runCommand("", "check-ignore", "-q", "x.log")
+	assert.Equal(t, status, exitOK)
+	assert.Equal(t, stdout, "")
+}
+
+func TestCheckIgnoreVerbose(t *testing.T) {
+	checkIgnoreTree(t)
+
+	status, stdout, _ := runCommand("", "check-ignore", "-v", "-n", "--no-index", "x.log", "keep.log", "main.go", "sub/x", "foo/a", "a.tmp")
+	assert.Equal(t, status, exitOK)
+	assert.Equal(t, stdout, ".gitignore:3:*.log\tx.log\n"+
+		".gitignore:4:!keep.log\tkeep.log\n"+
+		"::\tmain.go\n"+
+		"sub/.gitignore:1:x\tsub/x\n"+
+		".gitignore:1:foo/\tfoo/a\n"+
+		".git/info/exclude:1:*.tmp\ta.tmp\n")
+
+	// a negated match counts as a match
+	status, _, _ = runCommand("", "check-ignore", "-v", "keep.log")
+	assert.Equal(t, status, exitOK)
+
+	// the paths are relative to the working directory
+	chdir(t, "sub")
+	status, stdout, _ = runCommand("", "check-ignore", "--verbose", "x", "../y.log")
+	assert.Equal(t, status, exitOK)
+	assert.Equal(t, stdout, "sub/.gitignore:1:x\tx\n.gitignore:3:*.log\t../y.log\n")
+}
+
+func TestCheckIgnoreIgnoreCase(t *testing.T) {
+	root := checkIgnoreTree(t)
+
+	status, stdout, _ := runCommand("", "check-ignore", "X.LOG", "Foo/a")
+	assert.Equal(t, status, checkIgnoreNoMatch)
+	assert.Equal(t, stdout, "")
+
+	// core.ignoreCase of the repository is used
+	assert.Nil(t, os.WriteFile(filepath.Join(root, ".git", "config"), []byte("[core]\n\tignorecase = true\n"), 0o644))
+	status, stdout, _ = runCommand("", "check-ignore", "-v", "X.LOG", "Foo/a", "KEEP.log")
+	assert.Equal(t, status, exitOK)
+	assert.Equal(t, stdout, ".gitignore:3:*.log\tX.LOG\n.gitignore:1:foo/\tFoo/a\n.gitignore:4:!keep.log\tKEEP.log\n")
+}
+
+func TestCheckIgnorePrecomposeUnicode(t *testing.T) {
+	root := checkIgnoreTree(t)
+	assert.Nil(t, os.WriteFile(filepath.Join(root, ".gitignore"), []byte("/caf\u00e9\n"), 0o644))
+
+	status, _, _ := runCommand("", "check-ignore", "cafe\u0301")
+	assert.Equal(t, status, checkIgnoreNoMatch)
+
+	// core.precomposeUnicode of the repository is used
+	assert.Nil(t, os.WriteFile(filepath.Join(root, ".git", "config"), []byte("[core]\n\tprecomposeunicode = true\n"), 0o644))
+	status, stdout, _ := runCommand("", "check-ignore", "cafe\u0301")
+	assert.Equal(t, status, exitOK)
+	assert.Equal(t, stdout, "\"cafe\\314\\201\"\n")
+}
+
+func TestCheckIgnoreStdin(t *testing.T) {
+	checkIgnoreTree(t)
+
+	status, stdout, _ := runCommand("x.log\n\"\\303\\251.log\"\nmain.go\n", "check-ignore", "--stdin")
+	assert.Equal(t, status, exitOK)
+	assert.Equal(t, stdout, "x.log\n\"\\303\\251.log\"\n")
+
+	status, stdout, _ = runCommand("x.log\x00main.go\x00", "check-ignore", "--stdin", "-z", "-v", "-n")
+	assert.Equal(t, status, exitOK)
+	assert.Equal(t, stdout, ".gitignore\x003\x00*.log\x00x.log\x00\x00\x00\x00main.go\x00")
+
+	status, _, stderr := runCommand("\"bad\n", "check-ignore", "--stdin")
+	assert.Equal(t, status, checkIgnoreFatal)
+	assert.Equal(t, stderr, "fatal: line is badly quoted: \"bad\n")
+}
+
+func TestCheckIgnoreErrors(t *testing.T) {
+	root := checkIgnoreTree(t)
+
+	for _, test := range []struct {
+		args   []string
+		stderr string
+	}{
+		{[]string{}, "no path specified"},
+		{[]string{"-n", "a"}, "--non-matching is only valid with --verbose"},
+		{[]string{"-z", "a"}, "-z only makes sense with --stdin"},
+		{[]string{"-q", "-v", "a"}, "cannot have both --quiet and --verbose"},
+		{[]string{"-q", "a", "b"}, "--quiet is only valid with a single pathname"},
+		{[]string{"--stdin", "a"}, "cannot specify pathnames with --stdin"},
+		{[]string{"../a"}, "../a: '../a' is outside repository at '" + root + "'"},
+	} {
+		status, _, stderr := runCommand("", append([]string{"check-ignore"}, test.args...)...)
+		assert.Equal(t, status, checkIgnoreFatal)
+		assert.Equal(t, stderr, "fatal: "+test.stderr+"\n")
+	}
+
+	status, _, _ := runCommand("", "check-ignore", "--unknown")
+	assert.Equal(t, status, checkIgnoreBadFlag)
+}
+
+func TestQuotePath(t *testing.T) {
+	for _, test := range []struct{ path, quoted string }{
+		{"plain name.txt", "plain name.txt"},
+		{"é", "\"\\303\\251\""},
+		{"a\"b\\c\td\n", "\"a\\\"b\\\\c\\td\\n\""},
+		{"\x01\x7f", "\"\\001\\177\""},
+	} {
+		assert.Equal(t, quotePath(test.path), test.quoted)
+		if test.quoted[0] == '"' {
+			unquoted, err := unquotePath(test.quoted)
+			assert.Nil(t, err)
+			assert.Equal(t, unquoted, test.path)
+		}
+	}
+	for _, bad := range []string{"\"", "\"a", "\"a\"b\"", "\"\\q\"", "\"\\48\"", "\"\\1\""} {
+		_, err := unquotePath(bad)
+		assert.NotNil(t, err, bad)
+	}
+}
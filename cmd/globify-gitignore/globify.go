@@ -0,0 +1,279 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aminya/globify-gitignore/lib"
+)
+
+const globifyUsage = `Usage: globify-gitignore [flags] [directory | file | -]
+
+Converts a gitignore to glob patterns. The argument is a directory that has a
+.gitignore (default "."), a gitignore file, or - to read the standard input.
+With -from hgignore or -from p4ignore, a Mercurial .hgignore or a Perforce
+.p4ignore is read instead. With -format hgignore or -format p4ignore, the
+patterns are written as a .hgignore or a .p4ignore.
+
+The exit status is 1 if some entries are malformed (they are skipped), and 2 for
+the other errors.
+
+Flags:
+`
+
+func runGlobify(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("globify-gitignore", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	directory := flags.String("dir", "", "the directory that the globs are relative to (default: the directory of the gitignore, none for the standard input)")
+	dialectName := flags.String("dialect", "globby", "the glob syntax of the output: globby or doublestar")
+	format := flags.String("format", "lines", "the output format: lines, nul (NUL-separated), json (an array), hgignore (a Mercurial .hgignore instead of globs), or p4ignore (a Perforce .p4ignore)")
+	from := flags.String("from", "gitignore", "the format of the input: gitignore, hgignore (a Mercurial .hgignore), or p4ignore (a Perforce .p4ignore)")
+	strict := flags.Bool("strict", false, "parse the lines exactly like git (the leading whitespace is part of the patterns)")
+	ignoreCaseMode := flags.String("ignore-case", "false", "match the letters case-insensitively like git with core.ignoreCase: true, false, or auto (core.ignoreCase of the repository, false if it is not set). The globby globs then need the nocase option of the glob library.")
+	followSymlinks := flags.Bool("follow-symlinks", false, "convert the entries that are symbolic links to directories like directories (git never follows the links, so they are converted like files by default)")
+	normalizationName := flags.String("normalize", "none", "convert the entries to a Unicode normalization form: none, nfc, or nfd (e.g. for the decomposed names of macOS)")
+	baseName := flags.String("base", "as-is", "how the directory is emitted in the globs: as-is, absolute, relative (to the working directory), dot-relative (with ./), or omitted (with -format json, the output is an object with the cwd of the globs)")
+	flags.Usage = func() {
+		fmt.Fprint(stderr, globifyUsage)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return exitFatal
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return exitFatal
+	}
+
+	dialect, err := lib.ParseDialect(*dialectName)
+	if err != nil {
+		printError(stderr, err)
+		return exitFatal
+	}
+	normalization, err := lib.ParseNormalization(*normalizationName)
+	if err != nil {
+		printError(stderr, err)
+		return exitFatal
+	}
+	base, err := lib.ParseBaseMode(*baseName)
+	if err != nil {
+		printError(stderr, err)
+		return exitFatal
+	}
+	ignoreFileName := ".gitignore"
+	switch *from {
+	case "gitignore":
+	case "hgignore":
+		ignoreFileName = ".hgignore"
+	case "p4ignore":
+		ignoreFileName = ".p4ignore"
+	default:
+		printError(stderr, fmt.Errorf("unknown input format %q (expected gitignore, hgignore or p4ignore)", *from))
+		return exitFatal
+	}
+	input := "."
+	if flags.NArg() == 1 {
+		input = flags.Arg(0)
+	}
+
+	content, gitIgnoreDirectory, err := readGitIgnore(input, ignoreFileName, stdin)
+	if err != nil {
+		printError(stderr, err)
+		return exitFatal
+	}
+	var inputErr error
+	if *from != "gitignore" {
+		// the patterns are converted like the lines of a gitignore
+		var patterns []lib.Pattern
+		if *from == "hgignore" {
+			patterns, inputErr = lib.ParseHgIgnore(content)
+		} else {
+			patterns, inputErr = lib.ParseP4Ignore(content)
+		}
+		content = gitIgnoreContent(patterns)
+		*strict = true
+	}
+	switch *format {
+	case "hgignore":
+		return writeIgnoreFile(content, lib.RenderHgIgnore, *strict, input, inputErr, stdout, stderr)
+	case "p4ignore":
+		return writeIgnoreFile(content, lib.RenderP4Ignore, *strict, input, inputErr, stdout, stderr)
+	}
+	if *directory == "" {
+		*directory = gitIgnoreDirectory
+	}
+	ignoreCase, err := parseIgnoreCase(*ignoreCaseMode, gitIgnoreDirectory)
+	if err != nil {
+		printError(stderr, err)
+		return exitFatal
+	}
+
+	globSet, parseErr := lib.GlobifyGitIgnoreWithCwd(content, lib.GlobifyOptions{
+		Directory:      *directory,
+		Dialect:        dialect,
+		Strict:         *strict,
+		IgnoreCase:     ignoreCase,
+		Base:           base,
+		Normalization:  normalization,
+		FollowSymlinks: *followSymlinks,
+	})
+	if globSet.Globs == nil && parseErr != nil {
+		// the base directory could not be resolved
+		printError(stderr, parseErr)
+		return exitFatal
+	}
+	parseErr = errors.Join(inputErr, parseErr)
+	if base == lib.BaseOmitted && *format == "json" {
+		err = writeJSON(stdout, globSet)
+	} else {
+		err = writeList(stdout, globSet.Globs, *format)
+	}
+	if err != nil {
+		printError(stderr, err)
+		return exitFatal
+	}
+	if parseErr != nil {
+		printParseError(stderr, input, parseErr)
+		return exitParseError
+	}
+	return exitOK
+}
+
+/** Prints the errors of the entries of an ignore file, one per line */
+func printParseError(stderr io.Writer, input string, err error) {
+	for _, line := range strings.Split(err.Error(), "\n") {
+		fmt.Fprintf(stderr, "globify-gitignore: %s: %s\n", input, line)
+	}
+}
+
+/** The gitignore with the given patterns, one per line */
+func gitIgnoreContent(patterns []lib.Pattern) string {
+	var builder strings.Builder
+	for _, pattern := range patterns {
+		builder.WriteString(pattern.String())
+		builder.WriteByte('\n')
+	}
+	return builder.String()
+}
+
+/**
+ * Writes the gitignore as another ignore file (e.g. a Mercurial .hgignore). Without -strict, the lines are dedented and
+ * trimmed like for the globs.
+ */
+func writeIgnoreFile(
+	content string,
+	render func(patterns []lib.Pattern) (string, error),
+	strict bool,
+	input string,
+	inputErr error,
+	stdout io.Writer,
+	stderr io.Writer,
+) int {
+	if !strict {
+		lines := strings.Split(lib.Dedent(content), "\n")
+		for iLine, line := range lines {
+			lines[iLine] = lib.TrimWhiteSpace(line)
+		}
+		content = strings.Join(lines, "\n")
+	}
+	ignoreFile, err := render(lib.ParseGitIgnore(content))
+	if _, writeErr := io.WriteString(stdout, ignoreFile); writeErr != nil {
+		printError(stderr, writeErr)
+		return exitFatal
+	}
+	if err = errors.Join(inputErr, err); err != nil {
+		printParseError(stderr, input, err)
+		return exitParseError
+	}
+	return exitOK
+}
+
+/**
+ * Parses the value of -ignore-case. With auto, the case sensitivity of the repository that encloses the gitignore (or
+ * the working directory for the standard input) is detected.
+ */
+func parseIgnoreCase(mode string, gitIgnoreDirectory string) (bool, error) {
+	switch mode {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "auto":
+		if gitIgnoreDirectory == "" {
+			gitIgnoreDirectory = "."
+		}
+		absolute, err := filepath.Abs(gitIgnoreDirectory)
+		if err != nil {
+			return false, err
+		}
+		return lib.DetectIgnoreCase(findRoot(absolute))
+	default:
+		return false, fmt.Errorf("unknown -ignore-case %q (expected true, false or auto)", mode)
+	}
+}
+
+/**
+ * Reads the gitignore given on the command line. In a directory, the file with the given name is read.
+ *
+ * @returns {(string, string, error)} The content and the directory of the gitignore
+ */
+func readGitIgnore(input string, ignoreFileName string, stdin io.Reader) (string, string, error) {
+	if input == "-" {
+		content, err := io.ReadAll(stdin)
+		return string(content), "", err
+	}
+	info, err := os.Stat(input)
+	if err != nil {
+		return "", "", err
+	}
+	gitIgnoreFile := input
+	gitIgnoreDirectory := filepath.Dir(input)
+	if info.IsDir() {
+		gitIgnoreFile = filepath.Join(input, ignoreFileName)
+		gitIgnoreDirectory = input
+	}
+	content, err := os.ReadFile(gitIgnoreFile)
+	return string(content), gitIgnoreDirectory, err
+}
+
+/** Writes the list in the given format */
+func writeList(stdout io.Writer, list []string, format string) error {
+	switch format {
+	case "lines":
+		for _, item := range list {
+			if _, err := fmt.Fprintln(stdout, item); err != nil {
+				return err
+			}
+		}
+	case "nul":
+		for _, item := range list {
+			if _, err := fmt.Fprint(stdout, item, "\x00"); err != nil {
+				return err
+			}
+		}
+	case "json":
+		if list == nil {
+			list = []string{}
+		}
+		return writeJSON(stdout, list)
+	default:
+		return fmt.Errorf("unknown format %q (expected lines, nul or json)", format)
+	}
+	return nil
+}
+
+/** Writes a value as JSON without escaping the HTML characters of the globs */
+func writeJSON(stdout io.Writer, value interface{}) error {
+	encoder := json.NewEncoder(stdout)
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(value)
+}
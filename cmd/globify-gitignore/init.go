@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/aminya/globify-gitignore/lib"
+)
+
+const initUsage = `Usage: globify-gitignore init [flags]
+
+Writes a .gitignore composed of the gitignore templates that are embedded in
+the program (a snapshot of all the templates of github/gitignore, including
+Global/ and community/), so it works offline. Each template starts with a
+### Name ### header, and the patterns of an earlier template are not repeated.
+The names are case-insensitive, and a template whose file name is repeated is
+named by its path (e.g. Global/AL). An existing file is not overwritten without
+-force.
+
+The exit status is 2 if a template is unknown or the file cannot be written.
+
+Flags:
+`
+
+func runInit(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("init", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	templates := flags.String("template", "", "the comma-separated names of the templates (e.g. Go,Node,JetBrains)")
+	list := flags.Bool("list", false, "list the names of the templates instead")
+	search := flags.String("search", "", "list the names of the templates that contain the text instead")
+	output := flags.String("o", ".gitignore", "the file to write, or - for the standard output")
+	force := flags.Bool("force", false, "overwrite the file if it exists")
+	flags.Usage = func() {
+		fmt.Fprint(stderr, initUsage)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return exitFatal
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return exitFatal
+	}
+
+	if *list || *search != "" {
+		for _, name := range lib.SearchTemplates(*search) {
+			if _, err := fmt.Fprintln(stdout, name); err != nil {
+				printError(stderr, err)
+				return exitFatal
+			}
+		}
+		return exitOK
+	}
+	if *templates == "" {
+		printError(stderr, errors.New("no template (use -template, or -list to see the templates)"))
+		return exitFatal
+	}
+
+	names := []string{}
+	for _, name := range strings.Split(*templates, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	content, err := lib.ComposeTemplates(names)
+	if err != nil {
+		printError(stderr, err)
+		return exitFatal
+	}
+
+	if *output == "-" {
+		_, err = io.WriteString(stdout, content)
+	} else {
+		openFlag := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+		if *force {
+			openFlag = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		}
+		err = writeFile(*output, content, openFlag)
+	}
+	if err != nil {
+		printError(stderr, err)
+		return exitFatal
+	}
+	return exitOK
+}
+
+/** Writes the content to a file that is opened with the flag of `os.OpenFile` */
+func writeFile(name string, content string, openFlag int) error {
+	file, err := os.OpenFile(name, openFlag, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(file, content); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
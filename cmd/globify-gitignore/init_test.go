@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInit(t *testing.T) {
+	chdir(t, t.TempDir())
+
+	status, stdout, stderr := runCommand("", "init", "-template", "go, JetBrains")
+	assert.Equal(t, status, exitOK)
+	assert.Equal(t, stdout, "")
+	assert.Equal(t, stderr, "")
+	content, err := os.ReadFile(".gitignore")
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(string(content), "### Go ###\n"))
+	assert.Contains(t, string(content), "\n\n### JetBrains ###\n")
+
+	// an existing file is not overwritten without -force
+	status, _, stderr = runCommand("", "init", "-template", "Node")
+	assert.Equal(t, status, exitFatal)
+	assert.Contains(t, stderr, ".gitignore")
+	status, _, _ = runCommand("", "init", "-template", "Node", "-force")
+	assert.Equal(t, status, exitOK)
+	content, err = os.ReadFile(".gitignore")
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(string(content), "### Node ###\n"))
+
+	status, stdout, _ = runCommand("", "init", "-template", "Rust", "-o", "-")
+	assert.Equal(t, status, exitOK)
+	assert.True(t, strings.HasPrefix(stdout, "### Rust ###\n"))
+	status, _, _ = runCommand("", "init", "-template", "Rust", "-o", filepath.Join("sub", ".gitignore"))
+	assert.Equal(t, status, exitFatal)
+}
+
+func TestInitList(t *testing.T) {
+	status, stdout, _ := runCommand("", "init", "-search", "jet")
+	assert.Equal(t, status, exitOK)
+	assert.Equal(t, stdout, "JetBrains\n")
+
+	status, stdout, _ = runCommand("", "init", "-list")
+	assert.Equal(t, status, exitOK)
+	assert.Contains(t, stdout, "\nGo\n")
+}
+
+func TestInitErrors(t *testing.T) {
+	chdir(t, t.TempDir())
+
+	status, _, stderr := runCommand("", "init", "-template", "Go,Cobol")
+	assert.Equal(t, status, exitFatal)
+	assert.Equal(t, stderr, "globify-gitignore: unknown template \"Cobol\"\n")
+	_, err := os.Stat(".gitignore")
+	assert.True(t, os.IsNotExist(err))
+
+	status, _, _ = runCommand("", "init")
+	assert.Equal(t, status, exitFatal)
+	status, _, _ = runCommand("", "init", "-template", "Go", "extra")
+	assert.Equal(t, status, exitFatal)
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aminya/globify-gitignore/lib"
+)
+
+const lsFilesUsage = `Usage: globify-gitignore ls-files [flags] [directory]
+
+Lists the files of the directory (default ".") that are not ignored by its
+.gitignore files, like git ls-files lists the files of a repository. The paths
+are sorted like git, and the .git directory is never listed. Like git, the paths
+are compared case-insensitively if core.ignoreCase is set in the config of the
+enclosing repository, and they are precomposed if core.precomposeUnicode is set.
+
+The exit status is 2 if some directories cannot be read.
+
+Flags:
+`
+
+func runLsFiles(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("ls-files", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	var ignored, directory, nulTerminated bool
+	flags.BoolVar(&ignored, "ignored", false, "list the ignored files instead")
+	flags.BoolVar(&ignored, "i", false, "the same as --ignored")
+	flags.BoolVar(&directory, "directory", false, "list a directory whose files are all ignored as dir/ instead of its files (with --ignored)")
+	flags.BoolVar(&nulTerminated, "z", false, "separate the paths by NUL, and do not quote them")
+	excludeFiles := []string{}
+	flags.Func("exclude-from", "read more patterns from the file. They apply before the .gitignore files (can be repeated).", func(file string) error {
+		excludeFiles = append(excludeFiles, file)
+		return nil
+	})
+	flags.Usage = func() {
+		fmt.Fprint(stderr, lsFilesUsage)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return exitFatal
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return exitFatal
+	}
+	root := "."
+	if flags.NArg() == 1 {
+		root = flags.Arg(0)
+	}
+
+	excludes := []lib.Pattern{}
+	for _, excludeFile := range excludeFiles {
+		content, err := os.ReadFile(excludeFile)
+		if err != nil {
+			printError(stderr, err)
+			return exitFatal
+		}
+		patterns := lib.ParseGitIgnore(string(content))
+		for iPattern := range patterns {
+			patterns[iPattern].Source = excludeFile
+		}
+		excludes = append(excludes, patterns...)
+	}
+
+	if _, err := os.Stat(root); err != nil {
+		printError(stderr, err)
+		return exitFatal
+	}
+	absoluteRoot, err := filepath.Abs(root)
+	if err != nil {
+		printError(stderr, err)
+		return exitFatal
+	}
+	options, err := repositoryWalkOptions(findRoot(absoluteRoot), excludes)
+	if err != nil {
+		printError(stderr, err)
+		return exitFatal
+	}
+	lister := &fileLister{
+		fsys:      os.DirFS(root),
+		tree:      lib.NewIgnoreTree(os.DirFS(root), ".", options),
+		ignored:   ignored,
+		directory: directory,
+		stderr:    stderr,
+	}
+	files, _, _ := lister.list(".", false)
+	sort.Strings(files)
+
+	prefix := ""
+	if root != "." {
+		prefix = strings.TrimSuffix(path.Clean(filepath.ToSlash(root)), "/") + "/"
+	}
+	for _, file := range files {
+		file = prefix + file
+		var err error
+		if nulTerminated {
+			_, err = fmt.Fprintf(stdout, "%s\x00", file)
+		} else {
+			_, err = fmt.Fprintf(stdout, "%s\n", quotePath(file))
+		}
+		if err != nil {
+			printError(stderr, err)
+			return exitFatal
+		}
+	}
+	if lister.failed {
+		return exitFatal
+	}
+	return exitOK
+}
+
+/** Lists the files of a tree that are ignored or not */
+type fileLister struct {
+	fsys fs.FS
+	tree *lib.IgnoreTree
+	/** List the ignored files instead of the non-ignored ones */
+	ignored bool
+	/** Collapse the directories whose files are all ignored */
+	directory bool
+	stderr    io.Writer
+	/** Some directories could not be read */
+	failed bool
+}
+
+/**
+ * Lists the files of a directory
+ *
+ * @param {string} directory The directory relative to the root
+ * @param {bool} parentIgnored Whether the directory is ignored
+ * @returns {([]string, bool, bool)} The listed paths, whether the directory has files, and whether all of them are
+ *   ignored
+ */
+func (lister *fileLister) list(directory string, parentIgnored bool) ([]string, bool, bool) {
+	entries, err := fs.ReadDir(lister.fsys, directory)
+	if err != nil {
+		printError(lister.stderr, err)
+		lister.failed = true
+		return nil, false, false
+	}
+	var matcher *lib.Matcher
+	if !parentIgnored {
+		// the ignore files inside an ignored directory have no effect
+		if matcher, err = lister.tree.Matcher(directoryRelative(directory)); err != nil {
+			printError(lister.stderr, err)
+			lister.failed = true
+			return nil, false, false
+		}
+	}
+
+	files := []string{}
+	hasFiles, allIgnored := false, true
+	for _, entry := range entries {
+		if entry.Name() == ".git" {
+			continue
+		}
+		name := path.Join(directory, entry.Name())
+		ignored := parentIgnored
+		if !ignored {
+			pattern := matcher.MatchingPattern(name, entry.IsDir())
+			ignored = pattern != nil && !pattern.Negated
+		}
+
+		switch {
+		case !entry.IsDir():
+			hasFiles = true
+			allIgnored = allIgnored && ignored
+			if ignored == lister.ignored {
+				files = append(files, name)
+			}
+		case ignored && !lister.ignored:
+			// nothing inside an ignored directory is listed, so it is not read
+			hasFiles = true
+		case ignored && lister.directory:
+			files = append(files, name+"/")
+			hasFiles = true
+		default:
+			children, childrenHaveFiles, childrenIgnored := lister.list(name, ignored)
+			if !childrenHaveFiles {
+				continue
+			}
+			hasFiles = true
+			allIgnored = allIgnored && childrenIgnored
+			if lister.ignored && lister.directory && childrenIgnored {
+				children = []string{name + "/"}
+			}
+			files = append(files, children...)
+		}
+	}
+	return files, hasFiles, allIgnored
+}
+
+/** The directory relative to the root of the tree. Empty for the root. */
+func directoryRelative(directory string) string {
+	if directory == "." {
+		return ""
+	}
+	return directory
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/** Creates a tree that is not a repository */
+func lsFilesTree(t *testing.T) string {
+	root := t.TempDir()
+	files := map[string]string{
+		".git/HEAD":      "",
+		".gitignore":     "*.log\nbuild/\n",
+		"a/x.log":        "",
+		"a/y.log":        "",
+		"build/x/o":      "",
+		"build/z":        "",
+		"c/d/q.log":      "",
+		"c/r":            "",
+		"c/.gitignore":   "!keep.log\n",
+		"c/keep.log":     "",
+		"main.go":        "",
+		"extra.txt":      "",
+		"sp ace/é.txt":   "",
+		"empty/.gitkeep": "",
+	}
+	writeFiles(t, root, files)
+	return root
+}
+
+func TestLsFiles(t *testing.T) {
+	root := lsFilesTree(t)
+
+	status, stdout, stderr := runCommand("", "ls-files", root)
+	assert.Equal(t, status, exitOK)
+	assert.Equal(t, stderr, "")
+	assert.Equal(t, stdout, root+"/.gitignore\n"+
+		root+"/c/.gitignore\n"+
+		root+"/c/keep.log\n"+
+		root+"/c/r\n"+
+		root+"/empty/.gitkeep\n"+
+		root+"/extra.txt\n"+
+		root+"/main.go\n"+
+		"\""+root+"/sp ace/\\303\\251.txt\"\n")
+
+	chdir(t, root)
+	status, stdout, _ = runCommand("", "ls-files", "-z")
+	assert.Equal(t, status, exitOK)
+	assert.Equal(t, stdout, ".gitignore\x00c/.gitignore\x00c/keep.log\x00c/r\x00empty/.gitkeep\x00extra.txt\x00main.go\x00sp ace/é.txt\x00")
+}
+
+func TestLsFilesIgnored(t *testing.T) {
+	chdir(t, lsFilesTree(t))
+
+	status, stdout, _ := runCommand("", "ls-files", "--ignored")
+	assert.Equal(t, status, exitOK)
+	assert.Equal(t, stdout, "a/x.log\na/y.log\nbuild/x/o\nbuild/z\nc/d/q.log\n")
+
+	// build is ignored, and all the files of a and c/d are ignored
+	status, stdout, _ = runCommand("", "ls-files", "--ignored", "--directory")
+	assert.Equal(t, status, exitOK)
+	assert.Equal(t, stdout, "a/\nbuild/\nc/d/\n")
+}
+
+func TestLsFilesExcludeFrom(t *testing.T) {
+	root := lsFilesTree(t)
+	excludeFile := filepath.Join(t.TempDir(), "exclude")
+	assert.Nil(t, os.WriteFile(excludeFile, []byte("*.txt\n/c/\n"), 0o644))
+	chdir(t, root)
+
+	status, stdout, _ := runCommand("", "ls-files", "--exclude-from", excludeFile)
+	assert.Equal(t, status, exitOK)
+	assert.Equal(t, stdout, ".gitignore\nempty/.gitkeep\nmain.go\n")
+
+	// the .gitignore files apply after the excludes
+	assert.Nil(t, os.WriteFile(excludeFile, []byte("!*.log\n"), 0o644))
+	status, stdout, _ = runCommand("", "ls-files", "-i", "--exclude-from", excludeFile)
+	assert.Equal(t, status, exitOK)
+	assert.Equal(t, stdout, "a/x.log\na/y.log\nbuild/x/o\nbuild/z\nc/d/q.log\n")
+
+	status, _, _ = runCommand("", "ls-files", "--exclude-from", filepath.Join(root, "missing"))
+	assert.Equal(t, status, exitFatal)
+	status, _, _ = runCommand("", "ls-files", filepath.Join(root, "missing"))
+	assert.Equal(t, status, exitFatal)
+}
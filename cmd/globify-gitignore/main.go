@@ -0,0 +1,46 @@
+// Command globify-gitignore converts gitignore files to glob patterns.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+/** The exit statuses of the commands */
+const (
+	exitOK = 0
+	/** Some entries could not be parsed */
+	exitParseError = 1
+	/** Invalid arguments or an I/O error */
+	exitFatal = 2
+)
+
+/** A subcommand. It returns the exit status. */
+type command func(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int
+
+/** The subcommands. Without a subcommand, the gitignore is converted to globs. */
+var commands = map[string]command{
+	"check-ignore": runCheckIgnore,
+	"init":         runInit,
+	"ls-files":     runLsFiles,
+	"pack-files":   runPackFiles,
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	if len(args) != 0 {
+		if subcommand, ok := commands[args[0]]; ok {
+			return subcommand(args[1:], stdin, stdout, stderr)
+		}
+	}
+	return runGlobify(args, stdin, stdout, stderr)
+}
+
+/** Prints an error prefixed with the name of the program */
+func printError(stderr io.Writer, err error) {
+	fmt.Fprintf(stderr, "globify-gitignore: %v\n", err)
+}
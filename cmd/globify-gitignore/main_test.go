@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/** Runs the command and returns the exit status, the output and the errors */
+func runCommand(stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	status := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return status, stdout.String(), stderr.String()
+}
+
+/** Writes the files of a map (the paths are relative to the directory) */
+func writeFiles(t *testing.T, directory string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(directory, filepath.FromSlash(name))
+		assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.Nil(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+}
+
+/** Changes the working directory until the end of the test */
+func chdir(t *testing.T, directory string) {
+	previous, err := os.Getwd()
+	assert.Nil(t, err)
+	assert.Nil(t, os.Chdir(directory))
+	t.Cleanup(func() {
+		os.Chdir(previous)
+	})
+}
+
+func TestGlobifyStdin(t *testing.T) {
+	status, stdout, stderr := runCommand("node_modules\n/dist/\n", "-")
+	assert.Equal(t, status, exitOK)
+	assert.Equal(t, stderr, "")
+	assert.Equal(t, stdout, "!**/node_modules\n!**/node_modules/**\n!dist/**\n")
+
+	status, stdout, _ = runCommand("*.{js,ts}\n", "-format", "json", "-dialect", "doublestar", "-dir", "root", "-")
+	assert.Equal(t, status, exitOK)
+	assert.Equal(t, stdout, "[\"!root/**/*.\\\\{js,ts\\\\}\",\"!root/**/*.\\\\{js,ts\\\\}/*/**\"]\n")
+
+	status, stdout, _ = runCommand("a\n", "-format", "nul", "-")
+	assert.Equal(t, status, exitOK)
+	assert.Equal(t, stdout, "!**/a\x00!**/a/**\x00")
+	status, stdout, _ = runCommand(" a\r\n", "-strict", "-")
+	assert.Equal(t, status, exitOK)
+	assert.Equal(t, stdout, "!**/ a\n!**/ a/**\n")
+}
+
+func TestGlobifyFile(t *testing.T) {
+	directory := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(directory, ".gitignore"), []byte("*.log\n"), 0o644))
+
+	status, stdout, _ := runCommand("", directory)
+	assert.Equal(t, status, exitOK)
+	assert.Equal(t, stdout, "!"+directory+"/**/*.log\n!"+directory+"/**/*.log/**\n")
+
+	status, stdout, _ = runCommand("", "-dir", "base", filepath.Join(directory, ".gitignore"))
+	assert.Equal(t, status, exitOK)
+	assert.Equal(t, stdout, "!base/**/*.log\n!base/**/*.log/**\n")
+}
+
+func TestGlobifyIgnoreCase(t *testing.T) {
+	status, stdout, _ := runCommand("/Dist/\n", "-ignore-case", "true", "-dialect", "doublestar", "-")
+	assert.Equal(t, status, exitOK)
+	assert.Equal(t, stdout, "![dD][iI][sS][tT]/*/**\n")
+
+	// auto reads the config of the repository
+	directory := t.TempDir()
+	writeFiles(t, directory, map[string]string{
+		".git/config": "[core]\n\tignorecase = true\n",
+		".gitignore":  "/A\n",
+	})
+	status, stdout, _ = runCommand("", "-ignore-case", "auto", "-dialect", "doublestar", "-dir", "root", directory)
+	assert.Equal(t, status, exitOK)
+	assert.Equal(t, stdout, "!root/[aA]\n!root/[aA]/*/**\n")
+
+	// like git, it is false if core.ignoreCase is not set
+	directory = t.TempDir()
+	writeFiles(t, directory, map[string]string{
+		".git/config": "[core]\n\tbare = false\n",
+		".gitignore":  "/A\n",
+	})
+	status, stdout, _ = runCommand("", "-ignore-case", "auto", "-dialect", "doublestar", "-dir", "root", directory)
+	assert.Equal(t, status, exitOK)
+	assert.Equal(t, stdout, "!root/A\n!root/A/*/**\n")
+
+	status, _, stderr := runCommand("", "-ignore-case", "maybe", "-")
+	assert.Equal(t, status, exitFatal)
+	assert.Equal(t, stderr, "globify-gitignore: unknown -ignore-case \"maybe\" (expected true, false or auto)\n")
+}
+
+func TestGlobifyNormalize(t *testing.T) {
+	status, stdout, _ := runCommand("/caf\u00e9/\n", "-normalize", "nfd", "-")
+	assert.Equal(t, status, exitOK)
+	assert.Equal(t, stdout, "!cafe\u0301/**\n")
+
+	status, _, stderr := runCommand("", "-normalize", "nfkc", "-")
+	assert.Equal(t, status, exitFatal)
+	assert.Equal(t, stderr, "globify-gitignore: unknown normalization \"nfkc\" (expected one of none, nfc, nfd)\n")
+}
+
+func TestGlobifyFollowSymlinks(t *testing.T) {
+	directory := t.TempDir()
+	assert.Nil(t, os.Mkdir(filepath.Join(directory, "dir"), 0o755))
+	if err := os.Symlink("dir", filepath.Join(directory, "link")); err != nil {
+		t.Skip("symbolic links are not supported:", err)
+	}
+	assert.Nil(t, os.WriteFile(filepath.Join(directory, ".gitignore"), []byte("/link\n"), 0o644))
+
+	status, stdout, _ := runCommand("", directory)
+	assert.Equal(t, status, exitOK)
+	assert.Equal(t, stdout, "!"+directory+"/link\n")
+	status, stdout, _ = runCommand("", "-follow-symlinks", directory)
+	assert.Equal(t, status, exitOK)
+	assert.Equal(t, stdout, "!"+directory+"/link/**\n")
+}
+
+func TestGlobifyBase(t *testing.T) {
+	root := t.TempDir()
+	directory := filepath.Join(root, "[ci]")
+	assert.Nil(t, os.Mkdir(directory, 0o755))
+	assert.Nil(t, os.WriteFile(filepath.Join(directory, ".gitignore"), []byte("/dist/\n"), 0o644))
+	chdir(t, root)
+
+	status, stdout, _ := runCommand("", "[ci]")
+	assert.Equal(t, status, exitOK)
+	assert.Equal(t, stdout, "!\\[ci\\]/dist/**\n")
+	status, stdout, _ = runCommand("", "-base", "dot-relative", "-dialect", "doublestar", directory)
+	assert.Equal(t, status, exitOK)
+	assert.Equal(t, stdout, "!./\\[ci\\]/dist/*/**\n")
+	status, stdout, _ = runCommand("", "-base", "omitted", "-format", "json", "[ci]")
+	assert.Equal(t, status, exitOK)
+	assert.Equal(t, stdout, `{"cwd":"[ci]","globs":["!dist/**"]}`+"\n")
+
+	status, _, _ = runCommand("", "-base", "cwd", "[ci]")
+	assert.Equal(t, status, exitFatal)
+}
+
+func TestGlobifyHgIgnore(t *testing.T) {
+	directory := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(directory, ".hgignore"), []byte("\\.pyc$\nsyntax: glob\nbuild/\ninclude:other\n"), 0o644))
+
+	status, stdout, stderr := runCommand("", "-from", "hgignore", "-dir", "root", directory)
+	assert.Equal(t, status, exitParseError)
+	assert.Equal(t, stdout, "!root/**/*.pyc\n!root/**/*.pyc/**\n!root/**/build/**\n")
+	assert.Equal(t, stderr, "globify-gitignore: "+directory+": line 4: \"include:other\": unsupported pattern include\n")
+
+	status, stdout, _ = runCommand("  *.log\n  !keep.log\n  /dist\n", "-format", "hgignore", "-")
+	assert.Equal(t, status, exitOK)
+	assert.Equal(t, stdout, "syntax: glob\nre:^(?!(?:.*/)?keep\\.log$)(?:.*/)?[^/]*\\.log$\nrootglob:dist\n")
+
+	status, _, _ = runCommand("", "-from", "svnignore", "-")
+	assert.Equal(t, status, exitFatal)
+}
+
+func TestGlobifyP4Ignore(t *testing.T) {
+	directory := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(directory, ".p4ignore"), []byte("*.o\ndoc/*.md\n"), 0o644))
+
+	status, stdout, stderr := runCommand("", "-from", "p4ignore", "-dir", "root", directory)
+	assert.Equal(t, status, exitOK)
+	assert.Equal(t, stderr, "")
+	assert.Equal(t, stdout, "!root/**/*.o\n!root/**/doc/*.md\n!root/**/*.o/**\n!root/**/doc/*.md/**\n")
+
+	status, stdout, stderr = runCommand("  *.log\n  src/**/gen\n  a?\n", "-format", "p4ignore", "-")
+	assert.Equal(t, status, exitParseError)
+	assert.Equal(t, stdout, "*.log\n/src/gen\n/src/**/gen\n")
+	assert.Equal(t, stderr, "globify-gitignore: -: line 3: \"a?\": unsupported pattern: Perforce has no ? wildcard\n")
+}
+
+func TestGlobifyErrors(t *testing.T) {
+	status, stdout, stderr := runCommand("ok\nfoo[\n", "-")
+	assert.Equal(t, status, exitParseError)
+	assert.Equal(t, stdout, "!**/ok\n!**/ok/**\n")
+	assert.Equal(t, stderr, "globify-gitignore: -: line 2, column 4: \"foo[\": unterminated character class\n")
+
+	status, _, _ = runCommand("", filepath.Join(t.TempDir(), "missing"))
+	assert.Equal(t, status, exitFatal)
+	status, _, _ = runCommand("", "-format", "yaml", "-")
+	assert.Equal(t, status, exitFatal)
+	status, _, _ = runCommand("", "-dialect", "bash", "-")
+	assert.Equal(t, status, exitFatal)
+	status, _, _ = runCommand("", "a", "b")
+	assert.Equal(t, status, exitFatal)
+}
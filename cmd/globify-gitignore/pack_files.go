@@ -0,0 +1,76 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/aminya/globify-gitignore/lib"
+)
+
+const packFilesUsage = `Usage: globify-gitignore pack-files [flags] [directory]
+
+Lists the files that npm pack would include in the package of the directory
+(default "."), without running npm. The .npmignore of each directory applies
+like a .gitignore, and its .gitignore is used if it has no .npmignore. The files
+field of package.json selects the files, and package.json, the README, LICENSE
+and the files of main and bin are always included. The .git and node_modules
+directories are never included.
+
+The exit status is 2 if package.json or a directory cannot be read.
+
+Flags:
+`
+
+func runPackFiles(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("pack-files", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	nulTerminated := flags.Bool("z", false, "separate the paths by NUL, and do not quote them")
+	flags.Usage = func() {
+		fmt.Fprint(stderr, packFilesUsage)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return exitFatal
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return exitFatal
+	}
+	root := "."
+	if flags.NArg() == 1 {
+		root = flags.Arg(0)
+	}
+
+	files, err := lib.NpmPackFiles(os.DirFS(root), ".")
+	if err != nil {
+		printError(stderr, err)
+		return exitFatal
+	}
+
+	prefix := ""
+	if root != "." {
+		prefix = strings.TrimSuffix(path.Clean(filepath.ToSlash(root)), "/") + "/"
+	}
+	for _, file := range files {
+		file = prefix + file
+		var err error
+		if *nulTerminated {
+			_, err = fmt.Fprintf(stdout, "%s\x00", file)
+		} else {
+			_, err = fmt.Fprintf(stdout, "%s\n", quotePath(file))
+		}
+		if err != nil {
+			printError(stderr, err)
+			return exitFatal
+		}
+	}
+	return exitOK
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackFiles(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"package.json":        `{"files": ["lib"], "main": "index.js"}`,
+		"README.md":           "",
+		"index.js":            "",
+		"lib/a.js":            "",
+		"lib/a.test.js":       "",
+		"lib/.npmignore":      "*.test.js\n",
+		"test/a.js":           "",
+		"node_modules/x/a.js": "",
+	}
+	writeFiles(t, root, files)
+
+	status, stdout, stderr := runCommand("", "pack-files", root)
+	assert.Equal(t, status, exitOK)
+	assert.Equal(t, stderr, "")
+	assert.Equal(t, stdout, root+"/README.md\n"+root+"/index.js\n"+root+"/lib/a.js\n"+root+"/package.json\n")
+
+	chdir(t, root)
+	status, stdout, _ = runCommand("", "pack-files", "-z")
+	assert.Equal(t, status, exitOK)
+	assert.Equal(t, stdout, "README.md\x00index.js\x00lib/a.js\x00package.json\x00")
+}
+
+func TestPackFilesErrors(t *testing.T) {
+	status, stdout, stderr := runCommand("", "pack-files", t.TempDir())
+	assert.Equal(t, status, exitFatal)
+	assert.Equal(t, stdout, "")
+	assert.Contains(t, stderr, "package.json")
+
+	status, _, _ = runCommand("", "pack-files", "a", "b")
+	assert.Equal(t, status, exitFatal)
+}
@@ -1,11 +1,15 @@
 module github.com/aminya/globify-gitignore
 
-go 1.17
+go 1.21
+
+require (
+	github.com/bmatcuk/doublestar/v4 v4.10.2
+	github.com/stretchr/testify v1.7.0
+	golang.org/x/text v0.3.8
+)
 
 require (
 	github.com/davecgh/go-spew v1.1.0 // indirect
-	github.com/lithammer/dedent v1.1.0
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	github.com/stretchr/testify v1.7.0 // indirect
 	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
 )
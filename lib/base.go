@@ -0,0 +1,153 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/** How the directory of the gitignore (`GlobifyOptions.Directory`) is emitted at the start of the globs */
+type BaseMode uint
+
+const (
+	/** The cleaned directory as it is given (e.g. `root/` for `./root`). It is the default. */
+	BaseAsIs BaseMode = iota
+	/** The absolute directory (e.g. `/home/user/root/`) */
+	BaseAbsolute
+	/** The cleaned directory relative to `GlobifyOptions.RelativeTo` (e.g. `root/`, or nothing for the same directory) */
+	BaseRelative
+	/** Like `BaseRelative`, but the globs start with `./` (e.g. `./root/`, or `./` for the same directory) */
+	BaseDotRelative
+	/**
+	 * No directory. The globs are relative to the directory, so it should be the cwd of the glob library (see
+	 * `GlobifyGitIgnoreWithCwd`).
+	 */
+	BaseOmitted
+)
+
+/** The names of the base modes */
+var baseModeNames = []string{
+	BaseAsIs:        "as-is",
+	BaseAbsolute:    "absolute",
+	BaseRelative:    "relative",
+	BaseDotRelative: "dot-relative",
+	BaseOmitted:     "omitted",
+}
+
+/** The name of the base mode */
+func (mode BaseMode) String() string {
+	if int(mode) < len(baseModeNames) {
+		return baseModeNames[mode]
+	}
+	return fmt.Sprintf("BaseMode(%d)", uint(mode))
+}
+
+/**
+ * Get the base mode with the given name
+ *
+ * @param {string} name The name of the mode (e.g. `absolute` or `omitted`)
+ * @returns {(BaseMode, error)} The mode or an error if the name is unknown
+ */
+func ParseBaseMode(name string) (BaseMode, error) {
+	for iMode, modeName := range baseModeNames {
+		if modeName == name {
+			return BaseMode(iMode), nil
+		}
+	}
+	return BaseAsIs, fmt.Errorf("unknown base mode %q (expected one of %s)", name, strings.Join(baseModeNames, ", "))
+}
+
+/**
+ * The prefix of the globs: the directory in the form of the base mode, with its glob syntax escaped for the dialect,
+ * and a trailing `/`. It is empty if the globs are relative.
+ */
+func (options GlobifyOptions) basePrefix() (string, error) {
+	if options.Directory == "" {
+		return "", nil
+	}
+	directory := options.Directory
+	switch options.Base {
+	case BaseAsIs:
+		directory = cleanDirectory(PosixifyPath(directory))
+		if directory == "." {
+			return "", nil
+		}
+	case BaseAbsolute:
+		absolute, err := filepath.Abs(directory)
+		if err != nil {
+			return "", err
+		}
+		directory = absolute
+	case BaseRelative, BaseDotRelative:
+		relative, err := relativeDirectory(directory, options.RelativeTo)
+		if err != nil {
+			return "", err
+		}
+		directory = PosixifyPath(relative)
+		if options.Base == BaseRelative && directory == "." {
+			return "", nil
+		}
+		if options.Base == BaseDotRelative && directory != "." && directory != ".." && !strings.HasPrefix(directory, "../") {
+			directory = "./" + directory
+		}
+	case BaseOmitted:
+		return "", nil
+	default:
+		return "", fmt.Errorf("unknown base mode %s", options.Base)
+	}
+	prefix := options.Normalization.Normalize(globDirectoryPrefix(directory))
+	return options.Dialect.Escape(prefix), nil
+}
+
+/** The directory relative to another one (the current working directory if it is empty) */
+func relativeDirectory(directory string, relativeTo string) (string, error) {
+	if relativeTo == "" {
+		workingDirectory, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+		relativeTo = workingDirectory
+	}
+	absolute, err := filepath.Abs(directory)
+	if err != nil {
+		return "", err
+	}
+	absoluteRelativeTo, err := filepath.Abs(relativeTo)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Rel(absoluteRelativeTo, absolute)
+}
+
+/** The globs of a gitignore and the directory they are relative to */
+type GlobSet struct {
+	/**
+	 * The directory that the relative globs are relative to (e.g. the `cwd` option of globby). Empty for the current
+	 * working directory.
+	 */
+	Cwd string `json:"cwd"`
+	/** The globs */
+	Globs []string `json:"globs"`
+}
+
+/**
+ * Globify the content of a `.gitignore` file like `GlobifyGitIgnoreWithOptions`, and return the directory that the
+ * globs are relative to. It is the directory of the gitignore for `BaseOmitted`, and `RelativeTo` for `BaseRelative` and
+ * `BaseDotRelative`.
+ *
+ * @param {string} gitIgnoreContent The content of the gitignore file
+ * @param {GlobifyOptions} options The options of the conversion
+ * @returns {(GlobSet, error)} The globs and their cwd, and the errors of `GlobifyGitIgnoreWithOptions`
+ */
+func GlobifyGitIgnoreWithCwd(gitIgnoreContent string, options GlobifyOptions) (GlobSet, error) {
+	globs, err := GlobifyGitIgnoreWithOptions(gitIgnoreContent, options)
+	globSet := GlobSet{Globs: globs}
+	switch options.Base {
+	case BaseOmitted:
+		globSet.Cwd = PosixifyPath(options.Directory)
+	case BaseRelative, BaseDotRelative:
+		globSet.Cwd = PosixifyPath(options.RelativeTo)
+	}
+	return globSet, err
+}
@@ -0,0 +1,78 @@
+package lib
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBasePrefixEscaping(t *testing.T) {
+	// the checkouts of some CI services have glob characters in their paths
+	globs, err := GlobifyGitIgnoreWithOptions("/dist/\n", GlobifyOptions{Directory: "/builds/[ci]/a*b{1}(2)!"})
+	assert.Nil(t, err)
+	assert.Equal(t, globs, []string{`!/builds/\[ci\]/a\*b\{1\}\(2\)\!/dist/**`})
+
+	globs, err = GlobifyGitIgnoreWithOptions("/dist/\n", GlobifyOptions{Directory: "/builds/[ci]/a*b{1}(2)!", Dialect: DialectDoublestar})
+	assert.Nil(t, err)
+	assert.Equal(t, globs, []string{`!/builds/\[ci\]/a\*b\{1\}(2)!/dist/*/**`})
+
+	assert.Equal(t, GlobifyGitIgnoreEntry("x/", "/tmp/a?b"), []string{`!/tmp/a\?b/**/x/**`})
+}
+
+func TestBaseModes(t *testing.T) {
+	root := t.TempDir()
+	chdir(t, root)
+	directory := filepath.Join(root, "sub")
+	content := "/dist/\n"
+
+	cases := []struct {
+		options  GlobifyOptions
+		expected string
+	}{
+		{GlobifyOptions{Directory: "./sub/../sub/"}, "!sub/dist/**"},
+		{GlobifyOptions{Directory: "sub", Base: BaseAbsolute}, "!" + PosixifyPath(directory) + "/dist/**"},
+		{GlobifyOptions{Directory: directory, Base: BaseRelative}, "!sub/dist/**"},
+		{GlobifyOptions{Directory: "sub/../sub", Base: BaseDotRelative}, "!./sub/dist/**"},
+		{GlobifyOptions{Directory: root, Base: BaseRelative}, "!dist/**"},
+		{GlobifyOptions{Directory: root, Base: BaseDotRelative}, "!./dist/**"},
+		{GlobifyOptions{Directory: root, Base: BaseDotRelative, RelativeTo: directory}, "!../dist/**"},
+		{GlobifyOptions{Directory: ".hidden", Base: BaseDotRelative}, "!./.hidden/dist/**"},
+		{GlobifyOptions{Directory: directory, Base: BaseOmitted}, "!dist/**"},
+	}
+	for _, testCase := range cases {
+		globs, err := GlobifyGitIgnoreWithOptions(content, testCase.options)
+		assert.Nil(t, err)
+		assert.Equal(t, globs, []string{testCase.expected}, testCase.options.Base.String())
+	}
+
+	_, err := GlobifyGitIgnoreWithOptions(content, GlobifyOptions{Directory: "sub", Base: BaseMode(9)})
+	assert.EqualError(t, err, "unknown base mode BaseMode(9)")
+}
+
+func TestGlobifyGitIgnoreWithCwd(t *testing.T) {
+	globSet, err := GlobifyGitIgnoreWithCwd("*.log\n", GlobifyOptions{Directory: "/builds/[ci]", Base: BaseOmitted})
+	assert.Nil(t, err)
+	// the cwd is a path, so it is not escaped
+	assert.Equal(t, globSet, GlobSet{Cwd: "/builds/[ci]", Globs: []string{"!**/*.log", "!**/*.log/**"}})
+	encoded, err := json.Marshal(globSet)
+	assert.Nil(t, err)
+	assert.Equal(t, string(encoded), `{"cwd":"/builds/[ci]","globs":["!**/*.log","!**/*.log/**"]}`)
+
+	globSet, err = GlobifyGitIgnoreWithCwd("*.log\n", GlobifyOptions{Directory: "/repo/sub", Base: BaseRelative, RelativeTo: "/repo"})
+	assert.Nil(t, err)
+	assert.Equal(t, globSet, GlobSet{Cwd: "/repo", Globs: []string{"!sub/**/*.log", "!sub/**/*.log/**"}})
+
+	globSet, err = GlobifyGitIgnoreWithCwd("*.log\n", GlobifyOptions{Directory: "/repo"})
+	assert.Nil(t, err)
+	assert.Equal(t, globSet.Cwd, "")
+}
+
+func TestParseBaseMode(t *testing.T) {
+	mode, err := ParseBaseMode("dot-relative")
+	assert.Nil(t, err)
+	assert.Equal(t, mode, BaseDotRelative)
+	_, err = ParseBaseMode("cwd")
+	assert.EqualError(t, err, `unknown base mode "cwd" (expected one of as-is, absolute, relative, dot-relative, omitted)`)
+}
@@ -0,0 +1,91 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+const bazelIgnoreFileName = ".bazelignore"
+
+/**
+ * Parses the content of a Bazel `.bazelignore` file into gitignore patterns. Each line is a directory relative to the
+ * root of the workspace, which Bazel ignores with all of its files. The lines are trimmed, and the empty lines and the
+ * comments (`#`) are skipped. A line `bazel-out/` becomes the pattern `/bazel-out/`.
+ *
+ * NOTE: Bazel does not expand wildcards, and it does not support negations, so the lines with `*`, `?`, `[` or a
+ * leading `!` are reported instead of being read as literal names. The absolute paths and the paths outside the
+ * workspace are reported too.
+ *
+ * @param {string} bazelIgnoreContent The content of the bazelignore file
+ * @returns {([]Pattern, error)} The patterns in the order of the file, and an error that joins a `*ParseError` for each
+ *   line that is not a relative directory (`ErrUnsupportedPattern`), which is skipped
+ */
+func ParseBazelIgnore(bazelIgnoreContent string) ([]Pattern, error) {
+	return parseBazelIgnore(bazelIgnoreContent, "")
+}
+
+/** Like `ParseBazelIgnore`, but the source is copied to the patterns and to the errors */
+func parseBazelIgnore(bazelIgnoreContent string, source string) ([]Pattern, error) {
+	patterns := []Pattern{}
+	errs := []error{}
+	lines := strings.Split(strings.TrimPrefix(bazelIgnoreContent, byteOrderMark), "\n")
+	for iLine := range lines {
+		line := strings.TrimSpace(lines[iLine])
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		directory, err := bazelDirectory(line)
+		if err != nil {
+			errs = append(errs, &ParseError{Source: source, Line: iLine + 1, Pattern: line, Err: err})
+			continue
+		}
+		patterns = append(patterns, Pattern{
+			Text:     "/" + gitIgnoreEscape(directory),
+			DirOnly:  true,
+			Anchored: true,
+			Source:   source,
+			Line:     iLine + 1,
+		})
+	}
+	return patterns, errors.Join(errs...)
+}
+
+/** Get the clean directory of a line of a `.bazelignore`, or the error if Bazel would not ignore what it means */
+func bazelDirectory(line string) (string, error) {
+	if strings.ContainsAny(line, "*?[") {
+		return "", fmt.Errorf("%w: Bazel does not expand wildcards", ErrUnsupportedPattern)
+	}
+	if strings.HasPrefix(line, "!") {
+		return "", fmt.Errorf("%w: Bazel does not support negations", ErrUnsupportedPattern)
+	}
+	if strings.HasPrefix(line, "/") {
+		return "", fmt.Errorf("%w: the path is not relative to the workspace", ErrUnsupportedPattern)
+	}
+	directory := path.Clean(line)
+	if directory == "." || directory == ".." || strings.HasPrefix(directory, "../") {
+		return "", fmt.Errorf("%w: the path is not inside the workspace", ErrUnsupportedPattern)
+	}
+	return directory, nil
+}
+
+/**
+ * Reads the patterns of the `.bazelignore` of a workspace (see `ParseBazelIgnore`). A workspace without a
+ * `.bazelignore` has no patterns.
+ *
+ * @param {fs.FS} fsys The file system
+ * @param {string} workspace The root directory of the workspace. The patterns are relative to it.
+ * @returns {([]Pattern, error)} The patterns, and the errors of `ParseBazelIgnore` or of reading the `.bazelignore`
+ */
+func ReadBazelIgnore(fsys fs.FS, workspace string) ([]Pattern, error) {
+	content, err := fs.ReadFile(fsys, path.Join(workspace, bazelIgnoreFileName))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return []Pattern{}, nil
+		}
+		return nil, err
+	}
+	return parseBazelIgnore(string(content), bazelIgnoreFileName)
+}
@@ -0,0 +1,49 @@
+package lib
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBazelIgnore(t *testing.T) {
+	patterns, err := ParseBazelIgnore("node_modules\n  bazel-out/ \n# comment\n\n./third_party//vendor\nweird#name\n")
+	assert.Nil(t, err)
+	assert.Equal(t, patternStrings(patterns), []string{"/node_modules/", "/bazel-out/", "/third_party/vendor/", "/weird#name/"})
+	assert.Equal(t, patterns[2].Line, 5)
+
+	matcher := NewMatcher(patterns)
+	assert.True(t, matcher.Ignored("node_modules/x/BUILD", false))
+	assert.True(t, matcher.Ignored("third_party/vendor", true))
+	assert.False(t, matcher.Ignored("third_party/other/BUILD", false))
+	// the directories are only ignored at the root of the workspace
+	assert.False(t, matcher.Ignored("app/node_modules/x", false))
+	assert.False(t, matcher.Ignored("node_modules", false))
+}
+
+func TestParseBazelIgnoreErrors(t *testing.T) {
+	patterns, err := ParseBazelIgnore("ok\n*.o\n!keep\n/abs\n../up\n.\n")
+	assert.Equal(t, patternStrings(patterns), []string{"/ok/"})
+	assert.True(t, errors.Is(err, ErrUnsupportedPattern))
+	assert.Equal(t, strings.Split(err.Error(), "\n"), []string{
+		`line 2: "*.o": unsupported pattern: Bazel does not expand wildcards`,
+		`line 3: "!keep": unsupported pattern: Bazel does not support negations`,
+		`line 4: "/abs": unsupported pattern: the path is not relative to the workspace`,
+		`line 5: "../up": unsupported pattern: the path is not inside the workspace`,
+		`line 6: ".": unsupported pattern: the path is not inside the workspace`,
+	})
+}
+
+func TestReadBazelIgnore(t *testing.T) {
+	patterns, err := ReadBazelIgnore(fstest.MapFS{"ws/WORKSPACE": {}}, "ws")
+	assert.Nil(t, err)
+	assert.Equal(t, patterns, []Pattern{})
+
+	patterns, err = ReadBazelIgnore(fstest.MapFS{"ws/.bazelignore": {Data: []byte("out\n[x]\n")}}, "ws")
+	assert.Equal(t, patternStrings(patterns), []string{"/out/"})
+	assert.Equal(t, patterns[0].Source, ".bazelignore")
+	assert.Equal(t, err.Error(), `.bazelignore: line 2: "[x]": unsupported pattern: Bazel does not expand wildcards`)
+}
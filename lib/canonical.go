@@ -0,0 +1,82 @@
+package lib
+
+import (
+	"path"
+	"strings"
+)
+
+/**
+ * Converts a glob to its canonical form. The repeated separators are collapsed (e.g. `a//b` becomes `a/b`), and the
+ * consecutive `**` segments are merged into one. A leading `!`, a leading `/` or `//` (a UNC share), and a trailing `/`
+ * are kept. The escaped characters and the character classes are not changed.
+ *
+ * @param {string} glob The glob
+ * @returns {string} The canonical glob
+ */
+func CanonicalGlob(glob string) string {
+	if !strings.Contains(glob, "//") && !strings.Contains(glob, "**/**") {
+		// nothing to simplify
+		return glob
+	}
+	negation := ""
+	if strings.HasPrefix(glob, "!") {
+		negation, glob = "!", glob[1:]
+	}
+	root := ""
+	switch {
+	case strings.HasPrefix(glob, "//") && ParseWindowsPath(glob).Kind != WindowsPathRooted:
+		root = "//"
+	case strings.HasPrefix(glob, "/"):
+		root = "/"
+	}
+	rest := strings.TrimLeft(glob, "/")
+
+	segments := []string{}
+	for _, segment := range splitGlobSegments(rest) {
+		if segment == "" || (segment == "**" && len(segments) != 0 && segments[len(segments)-1] == "**") {
+			continue
+		}
+		segments = append(segments, segment)
+	}
+	canonical := negation + root + strings.Join(segments, "/")
+	if strings.HasSuffix(rest, "/") && len(segments) != 0 {
+		canonical += "/"
+	}
+	return canonical
+}
+
+/** Splits a glob at its separators. The escaped `/` and the `/` inside the character classes do not separate. */
+func splitGlobSegments(glob string) []string {
+	segments := []string{}
+	start := 0
+	for i := 0; i < len(glob); i++ {
+		switch glob[i] {
+		case '\\':
+			i++
+		case '[':
+			if end, err := characterClassEnd(glob, i); err == nil {
+				i = end
+			}
+		case '/':
+			segments = append(segments, glob[start:i])
+			start = i + 1
+		}
+	}
+	return append(segments, glob[start:])
+}
+
+/**
+ * Cleans a posix directory like `path.Clean` (e.g. `./fixtures/` becomes `fixtures`, and `a/../b` becomes `b`), but
+ * keeps the volume of a Windows path (e.g. `//server/share` or `C:`)
+ */
+func cleanDirectory(directory string) string {
+	windowsPath := ParseWindowsPath(directory)
+	if windowsPath.Volume == "" {
+		return path.Clean(directory)
+	}
+	volume := windowsPath.PosixVolume()
+	if windowsPath.Path == "" {
+		return volume
+	}
+	return volume + path.Clean(PosixifyPath(windowsPath.Path))
+}
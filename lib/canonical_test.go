@@ -0,0 +1,97 @@
+package lib
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var goldenUpdate = flag.Bool("golden.update", false, "rewrite the golden globs in testdata/golden")
+
+const goldenFixtures = "testdata/golden"
+
+func TestCanonicalGlob(t *testing.T) {
+	cases := []struct {
+		glob     string
+		expected string
+	}{
+		{"a/b", "a/b"},
+		{"a//b", "a/b"},
+		{"a///b//", "a/b/"},
+		{"/abs_dir/abs_dir//", "/abs_dir/abs_dir/"},
+		{"!a//b", "!a/b"},
+		{"**/**/x", "**/x"},
+		{"**/**/**", "**"},
+		{"x/**/**", "x/**"},
+		{"a/**/**/b/**/**/", "a/**/b/**/"},
+		{"!**/**/x/", "!**/x/"},
+		{"//server/share//x", "//server/share/x"},
+		{"//", "/"},
+		{"a\\//b", "a\\//b"},
+		{"a\\/\\/b", "a\\/\\/b"},
+		{"[/]//b", "[/]/b"},
+		{"a/***/**/b", "a/***/**/b"},
+	}
+	for _, testCase := range cases {
+		assert.Equal(t, CanonicalGlob(testCase.glob), testCase.expected, testCase.glob)
+		// it is idempotent
+		assert.Equal(t, CanonicalGlob(testCase.expected), testCase.expected, testCase.expected)
+	}
+}
+
+func TestCleanDirectory(t *testing.T) {
+	cases := []struct {
+		directory string
+		expected  string
+	}{
+		{"./fixtures/", "fixtures"},
+		{"sub/../sub/", "sub"},
+		{"./", "."},
+		{"../root", "../root"},
+		{"/a//b/", "/a/b"},
+		{"C:/a/../b", "C:/b"},
+		{"C:", "C:"},
+		{"//server/share/./dir/", "//server/share/dir"},
+	}
+	for _, testCase := range cases {
+		assert.Equal(t, cleanDirectory(testCase.directory), testCase.expected, testCase.directory)
+	}
+}
+
+/**
+ * Globifies the real-world gitignores of testdata/golden, and compares the globs with the `.golden` files next to them.
+ * Run with `-golden.update` to rewrite the golden files after an intended change of the output.
+ */
+func TestGolden(t *testing.T) {
+	names, err := filepath.Glob(filepath.Join(goldenFixtures, "*.gitignore"))
+	assert.Nil(t, err)
+	assert.NotEmpty(t, names)
+	for _, name := range names {
+		content, err := os.ReadFile(name)
+		assert.Nil(t, err)
+		for _, dialect := range []Dialect{DialectGlobby, DialectDoublestar} {
+			// the directory does not exist, so the globs do not depend on the files of the tree
+			globs, err := GlobifyGitIgnoreWithOptions(string(content), GlobifyOptions{Directory: "./root//", Dialect: dialect})
+			assert.Nil(t, err, name)
+			for _, glob := range globs {
+				assert.Equal(t, CanonicalGlob(glob), glob, name)
+			}
+
+			output := strings.Join(globs, "\n") + "\n"
+			goldenFile := strings.TrimSuffix(name, ".gitignore") + "." + dialect.String() + ".golden"
+			if *goldenUpdate {
+				assert.Nil(t, os.WriteFile(goldenFile, []byte(output), 0o644))
+				continue
+			}
+			expected, err := os.ReadFile(goldenFile)
+			if !assert.Nil(t, err, "run the tests with -golden.update to create %s", goldenFile) {
+				continue
+			}
+			assert.Equal(t, output, string(expected), goldenFile)
+		}
+	}
+}
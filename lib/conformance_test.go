@@ -0,0 +1,434 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	conformanceIterations = flag.Int("conformance.iterations", 30, "the number of random cases checked against git")
+	conformanceSeed       = flag.Int64("conformance.seed", 0, "the seed of the random cases (0 for the current time)")
+	conformanceUpdate     = flag.Bool("conformance.update", false, "save the minimised failing cases to testdata/conformance")
+)
+
+const conformanceFixtures = "testdata/conformance"
+
+/** A tree with ignore files and the answers of git for its paths */
+type conformanceCase struct {
+	/** The content of the ignore files by their posix path */
+	IgnoreFiles map[string]string `json:"ignoreFiles"`
+	Paths       []conformancePath `json:"paths"`
+	/** Run git with `core.ignoreCase` */
+	IgnoreCase bool `json:"ignoreCase,omitempty"`
+}
+
+type conformancePath struct {
+	Path string `json:"path"`
+	Dir  bool   `json:"dir,omitempty"`
+	/** The deciding pattern as `source:line:pattern` like `git check-ignore -v`, or empty if none matches */
+	Pattern string `json:"pattern"`
+}
+
+/** Is the path ignored according to its deciding pattern? */
+func (checked conformancePath) ignored() bool {
+	fields := strings.SplitN(checked.Pattern, ":", 3)
+	return len(fields) == 3 && !strings.HasPrefix(fields[2], "!")
+}
+
+/** Is a parent directory of the path re-included by a negated pattern? */
+func hasReincludedParent(name string, reincluded map[string]bool) bool {
+	for parent := path.Dir(name); parent != "."; parent = path.Dir(parent) {
+		if reincluded[parent] {
+			return true
+		}
+	}
+	return false
+}
+
+/** Creates the files, the directories, and the ignore files of the case in the directory */
+func (testCase conformanceCase) materialise(t *testing.T, root string) {
+	for _, checked := range testCase.Paths {
+		name := filepath.Join(root, filepath.FromSlash(checked.Path))
+		if checked.Dir {
+			assert.Nil(t, os.MkdirAll(name, 0o755))
+			continue
+		}
+		assert.Nil(t, os.MkdirAll(filepath.Dir(name), 0o755))
+		assert.Nil(t, os.WriteFile(name, []byte{}, 0o644))
+	}
+	for source, content := range testCase.IgnoreFiles {
+		name := filepath.Join(root, filepath.FromSlash(source))
+		assert.Nil(t, os.MkdirAll(filepath.Dir(name), 0o755))
+		assert.Nil(t, os.WriteFile(name, []byte(content), 0o644))
+	}
+}
+
+/**
+ * Checks the decisions of the library against the answers of the case. The matcher is checked for all the paths. The
+ * doublestar globs are checked for the files with doublestar itself rather than `Wildmatch`: they are evaluated in
+ * order like the patterns, so the last matching glob decides whether a file is ignored. The globs of the ignore files
+ * are combined like the globs of a single file (all the globs of the paths come first). The files under a directory
+ * that a negated pattern re-includes are skipped, as a list of globs cannot re-include a directory without its
+ * content.
+ *
+ * @returns {[]string} The mismatches
+ */
+func (testCase conformanceCase) mismatches(t *testing.T, root string) []string {
+	mismatches := []string{}
+
+	tree := NewIgnoreTree(os.DirFS(root), ".", WalkOptions{IgnoreCase: testCase.IgnoreCase})
+	for _, checked := range testCase.Paths {
+		pattern, err := tree.LastMatchingPattern(checked.Path, checked.Dir)
+		assert.Nil(t, err)
+		got := ""
+		if pattern != nil {
+			got = fmt.Sprintf("%s:%d:%s", pattern.Source, pattern.Line, pattern)
+		}
+		if got != checked.Pattern {
+			mismatches = append(mismatches, fmt.Sprintf("matcher: %s: got %q, git %q", checked.Path, got, checked.Pattern))
+		}
+	}
+
+	groups := &globGroups{}
+	// the deeper ignore files come last, as they take precedence
+	sources := sortedKeys(testCase.IgnoreFiles)
+	sort.SliceStable(sources, func(i, j int) bool {
+		return strings.Count(sources[i], "/") < strings.Count(sources[j], "/")
+	})
+	for _, source := range sources {
+		directory := filepath.Join(root, filepath.FromSlash(path.Dir(source)))
+		converted, _ := globifyGitIgnoreGroups(testCase.IgnoreFiles[source], GlobifyOptions{
+			Directory: directory,
+			Strict:    true,
+			// the letters of the doublestar globs are case-insensitive without an option
+			Dialect:    DialectDoublestar,
+			IgnoreCase: testCase.IgnoreCase,
+		})
+		groups.append(converted)
+	}
+	globs := uniqueGlobs(groups.slice())
+	reincluded := map[string]bool{}
+	for _, checked := range testCase.Paths {
+		if checked.Dir && !checked.ignored() && checked.Pattern != "" {
+			reincluded[checked.Path] = true
+		}
+	}
+	for _, checked := range testCase.Paths {
+		if checked.Dir || hasReincludedParent(checked.Path, reincluded) {
+			continue
+		}
+		fullName := PosixifyPath(filepath.Join(root, filepath.FromSlash(checked.Path)))
+		ignored := false
+		for _, glob := range globs {
+			matched, err := doublestar.Match(strings.TrimPrefix(glob, "!"), fullName)
+			if err != nil {
+				mismatches = append(mismatches, fmt.Sprintf("globs: %s: %v", glob, err))
+			} else if matched {
+				ignored = strings.HasPrefix(glob, "!")
+			}
+		}
+		if ignored != checked.ignored() {
+			mismatches = append(mismatches, fmt.Sprintf("globs: %s: got ignored=%t, git %q", checked.Path, ignored, checked.Pattern))
+		}
+	}
+	return mismatches
+}
+
+/** Runs git check-ignore in a new repository and sets the answers of the case */
+func (testCase *conformanceCase) askGit(t *testing.T, root string) {
+	home := t.TempDir()
+	environment := append(os.Environ(),
+		"HOME="+home, "XDG_CONFIG_HOME="+home, "GIT_CONFIG_NOSYSTEM=1", "GIT_CONFIG_GLOBAL="+os.DevNull,
+	)
+	initialize := exec.Command("git", "init", "-q")
+	initialize.Dir = root
+	initialize.Env = environment
+	if output, err := initialize.CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, output)
+	}
+
+	var input bytes.Buffer
+	for _, checked := range testCase.Paths {
+		input.WriteString(checked.Path)
+		input.WriteByte(0)
+	}
+	checkIgnore := exec.Command("git", "-c", fmt.Sprintf("core.ignorecase=%t", testCase.IgnoreCase),
+		"check-ignore", "--no-index", "-v", "-n", "-z", "--stdin")
+	checkIgnore.Dir = root
+	checkIgnore.Env = environment
+	checkIgnore.Stdin = &input
+	var stderr bytes.Buffer
+	checkIgnore.Stderr = &stderr
+	output, err := checkIgnore.Output()
+	if err != nil && checkIgnore.ProcessState.ExitCode() != 1 {
+		t.Fatalf("git check-ignore: %v: %s", err, stderr.String())
+	}
+
+	// source, line, pattern and path of each path
+	fields := strings.Split(strings.TrimSuffix(string(output), "\x00"), "\x00")
+	if len(fields) != 4*len(testCase.Paths) {
+		t.Fatalf("git check-ignore: unexpected output %q", output)
+	}
+	for iPath := range testCase.Paths {
+		source, line, pattern := fields[4*iPath], fields[4*iPath+1], fields[4*iPath+2]
+		testCase.Paths[iPath].Pattern = ""
+		if source != "" {
+			testCase.Paths[iPath].Pattern = source + ":" + line + ":" + pattern
+		}
+	}
+}
+
+/** Asks git and compares in a new directory */
+func (testCase *conformanceCase) run(t *testing.T) []string {
+	root := t.TempDir()
+	testCase.materialise(t, root)
+	testCase.askGit(t, root)
+	return testCase.mismatches(t, root)
+}
+
+/** Removes the paths and the patterns that are not needed to reproduce the mismatches */
+func (testCase conformanceCase) minimise(t *testing.T) conformanceCase {
+	// keep the first mismatching path and its directories, which decide whether it is skipped
+	for _, checked := range testCase.Paths {
+		candidate := testCase
+		candidate.Paths = []conformancePath{}
+		for _, other := range testCase.Paths {
+			if other.Dir && strings.HasPrefix(checked.Path, other.Path+"/") {
+				candidate.Paths = append(candidate.Paths, other)
+			}
+		}
+		candidate.Paths = append(candidate.Paths, checked)
+		if len(candidate.run(t)) != 0 {
+			testCase = candidate
+			break
+		}
+	}
+
+	// remove the lines one by one
+	for _, source := range sortedKeys(testCase.IgnoreFiles) {
+		lines := strings.Split(strings.TrimSuffix(testCase.IgnoreFiles[source], "\n"), "\n")
+		for iLine := 0; iLine < len(lines); {
+			remaining := append(append([]string{}, lines[:iLine]...), lines[iLine+1:]...)
+			candidate := testCase.withIgnoreFile(source, remaining)
+			if len(candidate.run(t)) != 0 {
+				testCase, lines = candidate, remaining
+				continue
+			}
+			iLine++
+		}
+	}
+	testCase.run(t)
+	return testCase
+}
+
+/** A copy of the case with other lines in an ignore file. An empty ignore file is removed. */
+func (testCase conformanceCase) withIgnoreFile(source string, lines []string) conformanceCase {
+	ignoreFiles := map[string]string{}
+	for otherSource, content := range testCase.IgnoreFiles {
+		ignoreFiles[otherSource] = content
+	}
+	delete(ignoreFiles, source)
+	if len(lines) != 0 {
+		ignoreFiles[source] = strings.Join(lines, "\n") + "\n"
+	}
+	return conformanceCase{
+		IgnoreFiles: ignoreFiles,
+		Paths:       append([]conformancePath{}, testCase.Paths...),
+		IgnoreCase:  testCase.IgnoreCase,
+	}
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+/** Saves the case as a regression fixture named after its content */
+func (testCase conformanceCase) save(t *testing.T) string {
+	content, err := json.MarshalIndent(testCase, "", "  ")
+	assert.Nil(t, err)
+	hash := fnv.New32a()
+	hash.Write(content)
+	name := filepath.Join(conformanceFixtures, fmt.Sprintf("%08x.json", hash.Sum32()))
+	assert.Nil(t, os.MkdirAll(conformanceFixtures, 0o755))
+	assert.Nil(t, os.WriteFile(name, append(content, '\n'), 0o644))
+	return name
+}
+
+/** The names of the random trees. They include the characters that are special only in the globs. */
+var conformanceNames = []string{"a", "b", "ab", "ba", "a.log", "b.txt", "c.log", "x{y}", "p(q)", "d!e", "A", ".h", "c d"}
+
+/** Generates a random tree and random ignore files */
+func randomConformanceCase(random *rand.Rand) conformanceCase {
+	kinds := map[string]bool{}
+	testCase := conformanceCase{IgnoreFiles: map[string]string{}, IgnoreCase: random.Intn(3) == 0}
+	directories := []string{""}
+
+	for iFile, count := 0, 5+random.Intn(15); iFile < count; iFile++ {
+		components := make([]string, 1+random.Intn(4))
+		for iComponent := range components {
+			components[iComponent] = conformanceNames[random.Intn(len(conformanceNames))]
+		}
+		name := strings.Join(components, "/")
+		// skip the files that would be a directory of another path (or the reverse)
+		conflict := false
+		for iComponent := range components {
+			isDir, ok := kinds[strings.Join(components[:iComponent+1], "/")]
+			if ok && isDir != (iComponent != len(components)-1) {
+				conflict = true
+			}
+		}
+		if conflict || kinds[name] {
+			continue
+		}
+		for iComponent := range components {
+			prefix := strings.Join(components[:iComponent+1], "/")
+			if _, ok := kinds[prefix]; ok {
+				continue
+			}
+			isDir := iComponent != len(components)-1
+			kinds[prefix] = isDir
+			testCase.Paths = append(testCase.Paths, conformancePath{Path: prefix, Dir: isDir})
+			if isDir {
+				directories = append(directories, prefix)
+			}
+		}
+	}
+	sort.Slice(testCase.Paths, func(i, j int) bool { return testCase.Paths[i].Path < testCase.Paths[j].Path })
+
+	negations := random.Intn(2) == 0
+	for _, directory := range directories {
+		if directory != "" && random.Intn(3) != 0 {
+			continue
+		}
+		lines := make([]string, 1+random.Intn(5))
+		for iLine := range lines {
+			lines[iLine] = randomPattern(random, negations)
+		}
+		testCase.IgnoreFiles[path.Join(directory, gitIgnoreFileName)] = strings.Join(lines, "\n") + "\n"
+	}
+	return testCase
+}
+
+/** Generates a random gitignore pattern from the names of the trees */
+func randomPattern(random *rand.Rand, negations bool) string {
+	segments := make([]string, 1+random.Intn(3))
+	for iSegment := range segments {
+		name := conformanceNames[random.Intn(len(conformanceNames))]
+		if random.Intn(5) == 0 {
+			name = strings.ToUpper(name)
+		}
+		switch random.Intn(8) {
+		case 0:
+			segments[iSegment] = "*"
+		case 1:
+			segments[iSegment] = "**"
+		case 2:
+			// a wildcard in place of a character
+			index := random.Intn(len(name))
+			segments[iSegment] = escapePattern(name[:index]) + string("?*"[random.Intn(2)]) + escapePattern(name[index+1:])
+		case 3:
+			classes := []string{"[ab]", "[!a]", "[^b]", "[a-c]", "[[:lower:]]", "[[:upper:]]", "[.]", "[A-C]", "[B]", "[!B]"}
+			segments[iSegment] = classes[random.Intn(len(classes))] + escapePattern(name[1:])
+		case 4:
+			segments[iSegment] = "*" + escapePattern(name[len(name)-1:])
+		default:
+			segments[iSegment] = escapePattern(name)
+		}
+	}
+	pattern := strings.Join(segments, "/")
+	if random.Intn(4) == 0 {
+		pattern = "/" + pattern
+	}
+	if random.Intn(4) == 0 {
+		pattern += "/"
+	}
+	if strings.HasPrefix(pattern, "!") || strings.HasPrefix(pattern, "#") {
+		pattern = "\\" + pattern
+	}
+	if negations && random.Intn(4) == 0 {
+		pattern = "!" + pattern
+	}
+	return pattern
+}
+
+/** Escapes the characters of a name that are special in gitignore */
+func escapePattern(name string) string {
+	var builder strings.Builder
+	for _, ch := range []byte(name) {
+		if strings.IndexByte("\\[]*?", ch) != -1 {
+			builder.WriteByte('\\')
+		}
+		builder.WriteByte(ch)
+	}
+	return builder.String()
+}
+
+/**
+ * Checks random cases against git. The seed is the current time by default, so each run checks new cases. A failure
+ * reports its seed, and `-conformance.seed` reruns the same cases.
+ */
+func TestConformance(t *testing.T) {
+	if testing.Short() {
+		t.Skip("the conformance tests run git")
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	seed := *conformanceSeed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	random := rand.New(rand.NewSource(seed))
+	t.Logf("seed %d", seed)
+
+	for iCase := 0; iCase < *conformanceIterations; iCase++ {
+		testCase := randomConformanceCase(random)
+		mismatches := testCase.run(t)
+		if len(mismatches) == 0 {
+			continue
+		}
+		minimised := testCase.minimise(t)
+		content, _ := json.MarshalIndent(minimised, "", "  ")
+		message := fmt.Sprintf("seed %d, case %d: %s\nminimised case:\n%s", seed, iCase, strings.Join(mismatches, "\n"), content)
+		if *conformanceUpdate {
+			message += "\nsaved to " + minimised.save(t)
+		}
+		t.Error(message)
+	}
+}
+
+/** Replays the saved cases. They have the answers of git, so git is not needed. */
+func TestConformanceFixtures(t *testing.T) {
+	names, err := filepath.Glob(filepath.Join(conformanceFixtures, "*.json"))
+	assert.Nil(t, err)
+	for _, name := range names {
+		content, err := os.ReadFile(name)
+		assert.Nil(t, err)
+		testCase := conformanceCase{}
+		assert.Nil(t, json.Unmarshal(content, &testCase), name)
+
+		root := t.TempDir()
+		testCase.materialise(t, root)
+		assert.Equal(t, testCase.mismatches(t, root), []string{}, name)
+	}
+}
@@ -0,0 +1,407 @@
+package lib
+
+import (
+	"fmt"
+	"strings"
+)
+
+/** The glob syntax of the output */
+type Dialect uint
+
+const (
+	/** The syntax of fast-glob, globby, micromatch and picomatch (the default) */
+	DialectGlobby Dialect = iota
+	/** The syntax of github.com/bmatcuk/doublestar. It has no extglobs and no POSIX character classes. */
+	DialectDoublestar
+)
+
+/** The names of the dialects */
+var dialectNames = []string{
+	DialectGlobby:     "globby",
+	DialectDoublestar: "doublestar",
+}
+
+/** The name of the dialect */
+func (dialect Dialect) String() string {
+	if int(dialect) < len(dialectNames) {
+		return dialectNames[dialect]
+	}
+	return fmt.Sprintf("Dialect(%d)", uint(dialect))
+}
+
+/**
+ * Get the dialect with the given name
+ *
+ * @param {string} name The name of the dialect (e.g. `globby` or `doublestar`)
+ * @returns {(Dialect, error)} The dialect or an error if the name is unknown
+ */
+func ParseDialect(name string) (Dialect, error) {
+	for iDialect, dialectName := range dialectNames {
+		if dialectName == name {
+			return Dialect(iDialect), nil
+		}
+	}
+	return DialectGlobby, fmt.Errorf("unknown dialect %q (expected one of %s)", name, strings.Join(dialectNames, ", "))
+}
+
+/** The characters that are literal in gitignore but special in the dialect */
+func (dialect Dialect) specialCharacters() string {
+	switch dialect {
+	case DialectDoublestar:
+		return "{}"
+	default:
+		// braces, extglobs and negation
+		return "{}()!"
+	}
+}
+
+/**
+ * Escapes the glob syntax of the dialect in a literal string (e.g. a directory like `/builds/[ci]/a*b`), so that the
+ * glob only matches the string itself
+ *
+ * @param {string} literal The string to escape
+ * @returns {string} The escaped string
+ */
+func (dialect Dialect) Escape(literal string) string {
+	special := "\\*?[]" + dialect.specialCharacters()
+	if !strings.ContainsAny(literal, special) {
+		return literal
+	}
+	var builder strings.Builder
+	builder.Grow(len(literal) + 4)
+	for i := 0; i < len(literal); i++ {
+		if strings.IndexByte(special, literal[i]) != -1 {
+			builder.WriteByte('\\')
+		}
+		builder.WriteByte(literal[i])
+	}
+	return builder.String()
+}
+
+/**
+ * Rewrites the trailing `/**` of a glob that only matches the content of the directories, for the dialects in which it
+ * also matches the path itself (e.g. `a/**` matches a file `a` in doublestar, so it becomes `a/*` + `/**`). The glob is
+ * canonical (see `CanonicalGlob`).
+ */
+func (dialect Dialect) renderContent(glob string) string {
+	if dialect != DialectDoublestar || !strings.HasSuffix(glob, "/**") {
+		return glob
+	}
+	return glob[:len(glob)-len("**")] + "*/**"
+}
+
+/**
+ * The glob of the content of the directories that a glob matches. A trailing `**` already matches the content of a
+ * directory, so its content is one level deeper (e.g. `a/**` gives `a/*` + `/**` rather than `a/**` again).
+ */
+func (dialect Dialect) contentGlob(glob string) string {
+	negation := ""
+	if strings.HasPrefix(glob, "!") {
+		negation, glob = "!", glob[1:]
+	}
+	glob = CanonicalGlob(glob)
+	if glob == "**" || strings.HasSuffix(glob, "/**") {
+		glob = glob[:len(glob)-len("**")] + "*/**"
+	} else {
+		glob += "/**"
+	}
+	return negation + dialect.renderContent(CanonicalGlob(glob))
+}
+
+/**
+ * Do the glob libraries of the dialect have a case-insensitive option (e.g. `caseSensitiveMatch: false` of fast-glob and
+ * globby, or `nocase` of micromatch and picomatch)? If so, the letters of the `IgnoreCase` globs are kept, and the
+ * option has to be set when matching. Otherwise, the letters are rendered as character classes (e.g. `[nN]`).
+ */
+func (dialect Dialect) HasNoCaseOption() bool {
+	return dialect == DialectGlobby
+}
+
+/** The ranges of the POSIX character classes for the dialects that do not support them */
+var posixClassRanges = map[string]string{
+	"alnum":  "a-zA-Z0-9",
+	"alpha":  "a-zA-Z",
+	"blank":  " \t",
+	"cntrl":  "\x00-\x1f\x7f",
+	"digit":  "0-9",
+	"graph":  "\"-~!",
+	"lower":  "a-z",
+	"print":  " -~",
+	"punct":  ":-@\\[-`{-~\"-/!",
+	"space":  " \t\n\v\f\r",
+	"upper":  "A-Z",
+	"xdigit": "0-9a-fA-F",
+}
+
+/**
+ * Checks that a gitignore pattern is well-formed. Git accepts the malformed patterns silently, but they never match.
+ *
+ * @param {string} pattern The pattern without the leading `!` and the trailing `/`
+ * @returns {error} The problem of the pattern (e.g. `ErrEmptyPattern` or `ErrUnterminatedCharacterClass`) or nil
+ */
+func CheckGitIgnorePattern(pattern string) error {
+	_, err := checkPattern(pattern)
+	return err
+}
+
+/** Like `CheckGitIgnorePattern`, but also returns the 0-based offset of the problem in the pattern */
+func checkPattern(pattern string) (int, error) {
+	if pattern == "" || pattern == "/" {
+		return 0, ErrEmptyPattern
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			i++
+			if i == len(pattern) {
+				return i - 1, ErrTrailingBackslash
+			}
+		case '[':
+			end, err := characterClassEnd(pattern, i)
+			if err != nil {
+				return end, err
+			}
+			i = end
+		}
+	}
+	return 0, nil
+}
+
+/**
+ * Finds the closing bracket of the character class that starts at the given index (like wildmatch). On errors, the
+ * offset of the problem is returned.
+ */
+func characterClassEnd(pattern string, start int) (int, error) {
+	i := start + 1
+	if charAt(pattern, i) == '!' || charAt(pattern, i) == '^' {
+		i++
+	}
+	// a `]` right after the opening bracket is literal
+	if charAt(pattern, i) == ']' {
+		i++
+	}
+	for ; i < len(pattern); i++ {
+		switch pattern[i] {
+		case ']':
+			return i, nil
+		case '\\':
+			i++
+		case '[':
+			if charAt(pattern, i+1) != ':' {
+				continue
+			}
+			closing := strings.IndexByte(pattern[i+2:], ']')
+			if closing == -1 {
+				return start, ErrUnterminatedCharacterClass
+			}
+			nameEnd := i + 2 + closing
+			if pattern[nameEnd-1] != ':' || nameEnd-1 < i+2 {
+				// not a [:class:], the `[` is literal
+				continue
+			}
+			name := pattern[i+2 : nameEnd-1]
+			if _, ok := posixClassRanges[name]; !ok {
+				return i, fmt.Errorf("%w [:%s:]", ErrUnknownCharacterClass, name)
+			}
+			i = nameEnd
+		}
+	}
+	return start, ErrUnterminatedCharacterClass
+}
+
+/**
+ * Converts a well-formed gitignore pattern (see `CheckGitIgnorePattern`) to the glob syntax of the dialect
+ *
+ * @param {string} pattern The pattern without the leading `!` and the trailing `/`
+ * @param {Dialect} dialect The glob syntax
+ * @returns {string} The glob
+ */
+func renderGlob(pattern string, dialect Dialect) string {
+	special := dialect.specialCharacters()
+	if pattern == "" || (pattern[0] != '!' && !strings.ContainsAny(pattern, special) && strings.IndexByte(pattern, '[') == -1) {
+		// nothing to render
+		return pattern
+	}
+	var builder strings.Builder
+	builder.Grow(len(pattern))
+	for i := 0; i < len(pattern); i++ {
+		ch := pattern[i]
+		switch {
+		case ch == '\\':
+			// escapes are the same in all the dialects
+			if i+1 == len(pattern) {
+				builder.WriteByte(ch)
+				break
+			}
+			builder.WriteString(pattern[i : i+2])
+			i++
+		case ch == '[':
+			end, err := characterClassEnd(pattern, i)
+			if err != nil {
+				builder.WriteString(pattern[i:])
+				return builder.String()
+			}
+			builder.WriteString(renderCharacterClass(pattern[i:end+1], dialect))
+			i = end
+		case strings.IndexByte(special, ch) != -1, ch == '!' && i == 0:
+			// a leading `!` would negate the glob in the list
+			builder.WriteByte('\\')
+			builder.WriteByte(ch)
+		default:
+			builder.WriteByte(ch)
+		}
+	}
+	return builder.String()
+}
+
+/**
+ * Converts a well-formed gitignore pattern to a gitignore pattern that matches case-sensitively what the pattern matches
+ * with `core.ignoreCase`. The character classes are rewritten as the ASCII characters they match, and the letters are
+ * rewritten as classes (e.g. `[nN]`) if foldLetters is true.
+ *
+ * @returns {(string, bool)} The pattern, and false if it can never match. Like git, an escaped capital letter (e.g.
+ *   `\A`) and a class of capital letters (e.g. `[A]`) never match with `core.ignoreCase`.
+ */
+func foldPatternCase(pattern string, foldLetters bool) (string, bool) {
+	var builder strings.Builder
+	builder.Grow(len(pattern))
+	for i := 0; i < len(pattern); i++ {
+		ch := pattern[i]
+		switch {
+		case ch == '\\':
+			if i+1 == len(pattern) {
+				builder.WriteByte(ch)
+				break
+			}
+			i++
+			if isUpper(pattern[i]) {
+				return "", false
+			}
+			if isLower(pattern[i]) {
+				writeLetterCase(&builder, pattern[i], foldLetters)
+			} else {
+				builder.WriteString(pattern[i-1 : i+1])
+			}
+		case ch == '[':
+			end, err := characterClassEnd(pattern, i)
+			if err != nil {
+				builder.WriteString(pattern[i:])
+				return builder.String(), true
+			}
+			class, ok := foldCharacterClassCase(pattern[i : end+1])
+			if !ok {
+				return "", false
+			}
+			builder.WriteString(class)
+			i = end
+		case isUpper(ch) || isLower(ch):
+			writeLetterCase(&builder, ch, foldLetters)
+		default:
+			builder.WriteByte(ch)
+		}
+	}
+	return builder.String(), true
+}
+
+func writeLetterCase(builder *strings.Builder, letter byte, foldLetters bool) {
+	if !foldLetters {
+		builder.WriteByte(letter)
+		return
+	}
+	builder.WriteByte('[')
+	builder.WriteByte(toLower(letter))
+	builder.WriteByte(toUpper(letter))
+	builder.WriteByte(']')
+}
+
+/** Rewrites a well-formed character class as the ASCII characters it matches with `core.ignoreCase` */
+func foldCharacterClassCase(class string) (string, bool) {
+	if !isASCII(class) {
+		// the non-ASCII bytes cannot be enumerated
+		return class, true
+	}
+	negated := class[1] == '!' || class[1] == '^'
+	members := []byte{}
+	for ch := byte(1); ch < 0x80; ch++ {
+		// like `?`, a class never matches `/` in a path
+		if ch != '/' && Wildmatch(class, string(rune(ch)), WildmatchCaseFold) != negated {
+			members = append(members, ch)
+		}
+	}
+	if len(members) == 0 {
+		if negated {
+			return "?", true
+		}
+		return "", false
+	}
+
+	var builder strings.Builder
+	builder.WriteByte('[')
+	if negated {
+		builder.WriteByte('!')
+	}
+	for i := 0; i < len(members); {
+		// the consecutive characters become a range unless they need escaping
+		end := i
+		for end+1 < len(members) && members[end+1] == members[end]+1 && !isClassSpecial(members[end+1]) {
+			end++
+		}
+		if isClassSpecial(members[i]) || end-i < 2 {
+			end = i
+			if isClassSpecial(members[i]) {
+				builder.WriteByte('\\')
+			}
+			builder.WriteByte(members[i])
+		} else {
+			builder.WriteByte(members[i])
+			builder.WriteByte('-')
+			builder.WriteByte(members[end])
+		}
+		i = end + 1
+	}
+	builder.WriteByte(']')
+	return builder.String(), true
+}
+
+/** The characters that are escaped in the rewritten character classes */
+func isClassSpecial(ch byte) bool {
+	return strings.IndexByte("\\[]!^-", ch) != -1
+}
+
+/** Converts a well-formed character class (including its brackets) to the dialect */
+func renderCharacterClass(class string, dialect Dialect) string {
+	var builder strings.Builder
+	builder.WriteByte('[')
+	i := 1
+	if class[i] == '!' || class[i] == '^' {
+		builder.WriteByte('!')
+		i++
+	}
+	for first := true; i < len(class)-1; i, first = i+1, false {
+		ch := class[i]
+		switch {
+		case ch == '\\':
+			builder.WriteString(class[i : i+2])
+			i++
+		case ch == '[' && class[i+1] == ':':
+			nameEnd := i + 2 + strings.IndexByte(class[i+2:], ']')
+			if class[nameEnd-1] != ':' || nameEnd-1 < i+2 {
+				builder.WriteString("\\[")
+				continue
+			}
+			if dialect == DialectDoublestar {
+				builder.WriteString(posixClassRanges[class[i+2:nameEnd-1]])
+			} else {
+				builder.WriteString(class[i : nameEnd+1])
+			}
+			i = nameEnd
+		case ch == ']' && first, ch == '[', ch == '!', ch == '^':
+			builder.WriteByte('\\')
+			builder.WriteByte(ch)
+		default:
+			builder.WriteByte(ch)
+		}
+	}
+	builder.WriteByte(']')
+	return builder.String()
+}
@@ -0,0 +1,156 @@
+package lib
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDialect(t *testing.T) {
+	dialect, err := ParseDialect("doublestar")
+	assert.Nil(t, err)
+	assert.Equal(t, dialect, DialectDoublestar)
+	assert.Equal(t, dialect.String(), "doublestar")
+
+	_, err = ParseDialect("bash")
+	assert.NotNil(t, err)
+}
+
+func TestCheckGitIgnorePattern(t *testing.T) {
+	assert.Nil(t, CheckGitIgnorePattern("*.js"))
+	assert.Nil(t, CheckGitIgnorePattern("a[]]b"))
+	assert.Nil(t, CheckGitIgnorePattern("[[:digit:]x]"))
+	assert.Nil(t, CheckGitIgnorePattern("[[:digit]ab]"))
+	assert.Nil(t, CheckGitIgnorePattern("\\[not a class"))
+	assert.EqualError(t, CheckGitIgnorePattern(""), "empty pattern")
+	assert.EqualError(t, CheckGitIgnorePattern("foo\\"), "trailing backslash")
+	assert.EqualError(t, CheckGitIgnorePattern("foo[ab"), "unterminated character class")
+	assert.EqualError(t, CheckGitIgnorePattern("[[:word:]]"), "unknown character class [:word:]")
+}
+
+func TestRenderGlob(t *testing.T) {
+	assert.Equal(t, renderGlob("**/*.{js,ts}", DialectGlobby), "**/*.\\{js,ts\\}")
+	assert.Equal(t, renderGlob("**/*.{js,ts}", DialectDoublestar), "**/*.\\{js,ts\\}")
+	assert.Equal(t, renderGlob("@(a)!", DialectGlobby), "@\\(a\\)\\!")
+	assert.Equal(t, renderGlob("@(a)!", DialectDoublestar), "@(a)!")
+	assert.Equal(t, renderGlob("!a", DialectDoublestar), "\\!a")
+	assert.Equal(t, renderGlob("\\#file\\ ", DialectGlobby), "\\#file\\ ")
+	assert.Equal(t, renderGlob("[^a-z]", DialectGlobby), "[!a-z]")
+	assert.Equal(t, renderGlob("a[]]b", DialectGlobby), "a[\\]]b")
+	assert.Equal(t, renderGlob("[[:digit:]x]", DialectGlobby), "[[:digit:]x]")
+	assert.Equal(t, renderGlob("[[:digit:]x]", DialectDoublestar), "[0-9x]")
+	assert.Equal(t, renderGlob("[![:upper:]]", DialectDoublestar), "[!A-Z]")
+}
+
+func TestFoldPatternCase(t *testing.T) {
+	fold := func(pattern string, foldLetters bool) string {
+		folded, ok := foldPatternCase(pattern, foldLetters)
+		if !ok {
+			return "never matches"
+		}
+		return folded
+	}
+	assert.Equal(t, fold("*.JS", true), "*.[jJ][sS]")
+	assert.Equal(t, fold("*.JS", false), "*.JS")
+	assert.Equal(t, fold("\\xy\\.z", true), "[xX][yY]\\.[zZ]")
+	assert.Equal(t, fold("[Q-T]x", false), "[Q-Tq-t]x")
+	assert.Equal(t, fold("[[:upper:]]", false), "[A-Za-z]")
+	assert.Equal(t, fold("[!a]", false), "[!Aa]")
+	assert.Equal(t, fold("[!]-]", false), "[!\\-\\]]")
+	assert.Equal(t, fold("[é]", false), "[é]")
+
+	// like git, an escaped capital letter and a class of capital letters never match
+	assert.Equal(t, fold("\\Abc", true), "never matches")
+	assert.Equal(t, fold("[A]bc", true), "never matches")
+	assert.Equal(t, fold("[!A]", true), "?")
+
+	assert.Equal(t, DialectGlobby.HasNoCaseOption(), true)
+	assert.Equal(t, DialectDoublestar.HasNoCaseOption(), false)
+}
+
+func FuzzFoldPatternCase(f *testing.F) {
+	f.Add("*.JS", "a/B.js")
+	f.Add("[!A-c]x", "Dx")
+	f.Add("\\a[[:upper:]]", "Ab")
+	f.Fuzz(func(t *testing.T, pattern string, text string) {
+		// like the paths, the patterns cannot have NUL bytes
+		if CheckGitIgnorePattern(pattern) != nil || strings.IndexByte(pattern+text, 0) != -1 {
+			return
+		}
+		for i := 0; i < len(pattern); i++ {
+			if pattern[i] >= 0x80 {
+				// the classes of non-ASCII bytes are not rewritten
+				return
+			}
+		}
+		// the folded pattern matches case-sensitively what the pattern matches case-insensitively
+		expected := Wildmatch(pattern, text, WildmatchPathname|WildmatchCaseFold)
+		folded, ok := foldPatternCase(pattern, true)
+		if !ok && expected {
+			t.Fatalf("%q never matches, but it matches %q", pattern, text)
+		}
+		if ok && Wildmatch(folded, text, WildmatchPathname) != expected {
+			t.Fatalf("%q (folded to %q) matches %q: %t", pattern, folded, text, expected)
+		}
+	})
+}
+
+/** Checks that a glob rendered for the dialect is well-formed and has no unescaped special characters */
+func checkGlob(glob string, dialect Dialect) error {
+	special := dialect.specialCharacters()
+	for i := 0; i < len(glob); i++ {
+		switch ch := glob[i]; {
+		case ch == '\\':
+			i++
+			if i == len(glob) {
+				return fmt.Errorf("trailing backslash")
+			}
+		case ch == '[':
+			start := i + 1
+			if charAt(glob, start) == '^' {
+				return fmt.Errorf("character class negated with ^ at %d", i)
+			}
+			if charAt(glob, start) == '!' {
+				start++
+			}
+			end := -1
+			for j := start; j < len(glob) && end == -1; j++ {
+				switch {
+				case glob[j] == '\\':
+					j++
+				case glob[j] == '[' && charAt(glob, j+1) == ':':
+					if dialect == DialectDoublestar {
+						return fmt.Errorf("POSIX character class at %d", j)
+					}
+					closing := strings.Index(glob[j:], ":]")
+					if closing == -1 {
+						return fmt.Errorf("unterminated POSIX character class at %d", j)
+					}
+					j += closing + 1
+				case glob[j] == '[':
+					return fmt.Errorf("unescaped [ in a character class at %d", j)
+				case glob[j] == ']':
+					end = j
+				}
+			}
+			if end == -1 {
+				return fmt.Errorf("unterminated character class at %d", i)
+			}
+			if end == start {
+				return fmt.Errorf("empty character class at %d", i)
+			}
+			i = end
+		case strings.IndexByte(special, ch) != -1:
+			return fmt.Errorf("unescaped %q at %d", ch, i)
+		}
+	}
+	return nil
+}
+
+func TestDialectEscape(t *testing.T) {
+	assert.Equal(t, DialectGlobby.Escape("/plain/path"), "/plain/path")
+	assert.Equal(t, DialectGlobby.Escape(`a*b?[c]\{d}(e)!`), `a\*b\?\[c\]\\\{d\}\(e\)\!`)
+	assert.Equal(t, DialectDoublestar.Escape(`a*b?[c]\{d}(e)!`), `a\*b\?\[c\]\\\{d\}(e)!`)
+}
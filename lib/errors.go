@@ -0,0 +1,78 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+/** The pattern is empty (e.g. `!`, `/` or `//`), so it can never match */
+var ErrEmptyPattern = errors.New("empty pattern")
+
+/** The pattern ends with an escaping backslash. Git never matches such patterns. */
+var ErrTrailingBackslash = errors.New("trailing backslash")
+
+/** A `[` of the pattern has no closing `]`. Git never matches such patterns. */
+var ErrUnterminatedCharacterClass = errors.New("unterminated character class")
+
+/** A `[:name:]` of the pattern is not a POSIX character class. Git never matches such patterns. */
+var ErrUnknownCharacterClass = errors.New("unknown character class")
+
+/** The pattern of another ignore format (e.g. a regexp of `.hgignore`) cannot be expressed with gitignore patterns */
+var ErrUnsupportedPattern = errors.New("unsupported pattern")
+
+/** An include directive (e.g. `#!include:.gitignore`) includes a file that is already being read */
+var ErrIncludeCycle = errors.New("include cycle")
+
+/** The name is not one of the embedded gitignore templates (see `TemplateNames`) */
+var ErrUnknownTemplate = errors.New("unknown template")
+
+/** A malformed gitignore entry */
+type ParseError struct {
+	/** The ignore file of the entry (if known) */
+	Source string
+	/** The 1-based line number of the entry, or 0 if unknown */
+	Line int
+	/** The 1-based column of the problem in the line, or 0 if unknown */
+	Column int
+	/** The entry */
+	Pattern string
+	/** The problem (e.g. `ErrEmptyPattern`) */
+	Err error
+}
+
+func (err *ParseError) Error() string {
+	position := []string{}
+	if err.Line != 0 {
+		position = append(position, fmt.Sprintf("line %d", err.Line))
+	}
+	if err.Column != 0 {
+		position = append(position, fmt.Sprintf("column %d", err.Column))
+	}
+	message := fmt.Sprintf("%q: %v", err.Pattern, err.Err)
+	if len(position) != 0 {
+		message = strings.Join(position, ", ") + ": " + message
+	}
+	if err.Source != "" {
+		message = err.Source + ": " + message
+	}
+	return message
+}
+
+func (err *ParseError) Unwrap() error {
+	return err.Err
+}
+
+/** The type of a path could not be probed (e.g. because of a permission error) */
+type PathProbeError struct {
+	Path string
+	Err  error
+}
+
+func (err *PathProbeError) Error() string {
+	return fmt.Sprintf("probe %s: %v", err.Path, err.Err)
+}
+
+func (err *PathProbeError) Unwrap() error {
+	return err.Err
+}
@@ -0,0 +1,24 @@
+package lib
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseError(t *testing.T) {
+	err := &ParseError{Source: ".gitignore", Line: 3, Column: 4, Pattern: "foo[", Err: ErrUnterminatedCharacterClass}
+	assert.EqualError(t, err, `.gitignore: line 3, column 4: "foo[": unterminated character class`)
+	assert.True(t, errors.Is(err, ErrUnterminatedCharacterClass))
+
+	assert.EqualError(t, &ParseError{Line: 1, Pattern: "!", Err: ErrEmptyPattern}, `line 1: "!": empty pattern`)
+	assert.EqualError(t, &ParseError{Pattern: "a\\", Err: ErrTrailingBackslash}, `"a\\": trailing backslash`)
+}
+
+func TestPathProbeError(t *testing.T) {
+	err := &PathProbeError{Path: "/root/dir", Err: fs.ErrPermission}
+	assert.EqualError(t, err, "probe /root/dir: permission denied")
+	assert.True(t, errors.Is(err, fs.ErrPermission))
+}
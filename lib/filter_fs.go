@@ -0,0 +1,204 @@
+package lib
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+/**
+ * Returns a view of the file system without the ignored files. The ignored paths (and the `.git` directory) behave as
+ * if they did not exist, so the view can be passed to any consumer of `fs.FS` (e.g. `http.FS`, `template.ParseFS`, or
+ * an archive writer).
+ *
+ * The patterns of the `.gitignore` files of the file system apply after the given rules, like in `Walk`.
+ *
+ * @param {fs.FS} fsys The file system to filter
+ * @param {*Matcher} rules The patterns relative to the root of fsys that apply before its ignore files. It can be nil.
+ * @returns {fs.FS} The filtered file system. It implements `fs.ReadDirFS`, `fs.ReadFileFS`, `fs.StatFS`, `fs.GlobFS`,
+ *   and `fs.SubFS`.
+ */
+func FilterFS(fsys fs.FS, rules *Matcher) fs.FS {
+	return &filterFS{
+		tree: NewIgnoreTree(fsys, ".", WalkOptions{Hidden: true, Matcher: rules}),
+	}
+}
+
+type filterFS struct {
+	tree *IgnoreTree
+	/** The directory of the view in the underlying file system (set by `Sub`). Empty for the root. */
+	prefix string
+}
+
+/** Converts a name of the view to a path of the underlying file system, or returns a not-exist error if it is ignored */
+func (fsys *filterFS) resolve(op string, name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	fullName := path.Join(fsys.prefix, name)
+	if fullName == "." || fullName == "" {
+		return ".", nil
+	}
+	ignored, err := fsys.ignored(fullName)
+	if err != nil {
+		return "", &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	if ignored {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return fullName, nil
+}
+
+/** Is the path of the underlying file system ignored? */
+func (fsys *filterFS) ignored(fullName string) (bool, error) {
+	for _, component := range strings.Split(fullName, "/") {
+		if component == ".git" {
+			return true, nil
+		}
+	}
+
+	matcher, err := fsys.tree.Matcher(parentDirectory(fullName))
+	if err != nil {
+		return false, err
+	}
+	ignoredAsFile := matcher.Ignored(fullName, false)
+	if ignoredAsFile == matcher.Ignored(fullName, true) {
+		return ignoredAsFile, nil
+	}
+	// only a directory (or only a file) is ignored
+	info, err := fs.Stat(fsys.tree.fsys, fullName)
+	if err != nil {
+		return false, err
+	}
+	return matcher.Ignored(fullName, info.IsDir()), nil
+}
+
+/** Removes the ignored entries of a directory */
+func (fsys *filterFS) filter(fullName string, entries []fs.DirEntry) ([]fs.DirEntry, error) {
+	filtered := make([]fs.DirEntry, 0, len(entries))
+	for _, entry := range entries {
+		ignored, _, _, err := fsys.tree.check(path.Join(fullName, entry.Name()), entry)
+		if err != nil {
+			return filtered, err
+		}
+		if !ignored {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered, nil
+}
+
+func (fsys *filterFS) Open(name string) (fs.File, error) {
+	fullName, err := fsys.resolve("open", name)
+	if err != nil {
+		return nil, err
+	}
+	file, err := fsys.tree.fsys.Open(fullName)
+	if err != nil {
+		return nil, err
+	}
+	if directory, ok := file.(fs.ReadDirFile); ok {
+		return &filterDirectory{ReadDirFile: directory, fsys: fsys, fullName: fullName}, nil
+	}
+	return file, nil
+}
+
+func (fsys *filterFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	fullName, err := fsys.resolve("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := fs.ReadDir(fsys.tree.fsys, fullName)
+	if err != nil {
+		return nil, err
+	}
+	return fsys.filter(fullName, entries)
+}
+
+func (fsys *filterFS) ReadFile(name string) ([]byte, error) {
+	fullName, err := fsys.resolve("readfile", name)
+	if err != nil {
+		return nil, err
+	}
+	return fs.ReadFile(fsys.tree.fsys, fullName)
+}
+
+func (fsys *filterFS) Stat(name string) (fs.FileInfo, error) {
+	fullName, err := fsys.resolve("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return fs.Stat(fsys.tree.fsys, fullName)
+}
+
+func (fsys *filterFS) Glob(pattern string) ([]string, error) {
+	// fs.Glob uses the filtered ReadDir and Stat, so the ignored paths never match
+	return fs.Glob(globFS{fsys}, pattern)
+}
+
+func (fsys *filterFS) Sub(dir string) (fs.FS, error) {
+	fullName, err := fsys.resolve("sub", dir)
+	if err != nil {
+		return nil, err
+	}
+	if fullName == "." {
+		return fsys, nil
+	}
+	// the patterns stay relative to the root of the underlying file system
+	return &filterFS{tree: fsys.tree, prefix: fullName}, nil
+}
+
+/** Hides the Glob method of filterFS from fs.Glob */
+type globFS struct {
+	fsys *filterFS
+}
+
+func (fsys globFS) Open(name string) (fs.File, error) {
+	return fsys.fsys.Open(name)
+}
+
+func (fsys globFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fsys.fsys.ReadDir(name)
+}
+
+func (fsys globFS) Stat(name string) (fs.FileInfo, error) {
+	return fsys.fsys.Stat(name)
+}
+
+/** A directory of the filtered file system */
+type filterDirectory struct {
+	fs.ReadDirFile
+	fsys     *filterFS
+	fullName string
+}
+
+func (directory *filterDirectory) ReadDir(count int) ([]fs.DirEntry, error) {
+	if count <= 0 {
+		entries, err := directory.ReadDirFile.ReadDir(count)
+		filtered, filterErr := directory.fsys.filter(directory.fullName, entries)
+		if err == nil {
+			err = filterErr
+		}
+		return filtered, err
+	}
+
+	// read until there are count non-ignored entries
+	result := []fs.DirEntry{}
+	for len(result) < count {
+		entries, err := directory.ReadDirFile.ReadDir(count - len(result))
+		filtered, filterErr := directory.fsys.filter(directory.fullName, entries)
+		result = append(result, filtered...)
+		if filterErr != nil {
+			return result, filterErr
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) && len(result) != 0 {
+				return result, nil
+			}
+			return result, err
+		}
+	}
+	return result, nil
+}
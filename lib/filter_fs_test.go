@@ -0,0 +1,74 @@
+package lib
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterFS(t *testing.T) {
+	fsys := FilterFS(testTree(), nil)
+
+	// checks the consistency of Open, ReadDir, ReadFile, Stat, Glob and Sub
+	assert.Nil(t, fstest.TestFS(fsys, "main.go", "src/app.go", "src/keep.log", ".gitignore"))
+
+	for _, name := range []string{"debug.log", "build", "build/out.bin", "src/other.log", "src/generated/x.go", ".git/config"} {
+		_, err := fsys.Open(name)
+		assert.True(t, errors.Is(err, fs.ErrNotExist), name)
+		_, err = fs.Stat(fsys, name)
+		assert.True(t, errors.Is(err, fs.ErrNotExist), name)
+	}
+
+	entries, err := fs.ReadDir(fsys, "src")
+	assert.Nil(t, err)
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.Equal(t, names, []string{".gitignore", "app.go", "keep.log", "nested"})
+
+	matches, err := fs.Glob(fsys, "*/*.log")
+	assert.Nil(t, err)
+	assert.Equal(t, matches, []string{"src/keep.log"})
+
+	files := []string{}
+	assert.Nil(t, fs.WalkDir(fsys, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() {
+			files = append(files, name)
+		}
+		return err
+	}))
+	assert.Equal(t, files, []string{".gitignore", ".hidden", "main.go", "src/.gitignore", "src/app.go", "src/keep.log"})
+}
+
+func TestFilterFSSub(t *testing.T) {
+	fsys := FilterFS(testTree(), NewMatcher(ParseGitIgnore("nested/\n")))
+
+	sub, err := fs.Sub(fsys, "src")
+	assert.Nil(t, err)
+	assert.Nil(t, fstest.TestFS(sub, "app.go", "keep.log"))
+
+	// the rules stay relative to the root of the original file system
+	_, err = fs.Stat(sub, "other.log")
+	assert.True(t, errors.Is(err, fs.ErrNotExist))
+	_, err = fs.Stat(sub, "nested")
+	assert.True(t, errors.Is(err, fs.ErrNotExist))
+
+	_, err = fs.Sub(fsys, "build")
+	assert.True(t, errors.Is(err, fs.ErrNotExist))
+}
+
+func TestFilterFSDirectoryOnlyPatterns(t *testing.T) {
+	fsys := FilterFS(fstest.MapFS{
+		"cache":     {Data: []byte{}},
+		"sub/cache": {Data: []byte{}},
+		"x/cache/y": {Data: []byte{}},
+	}, NewMatcher(ParseGitIgnore("cache/\n")))
+
+	assert.Nil(t, fstest.TestFS(fsys, "cache", "sub/cache", "x"))
+	_, err := fs.Stat(fsys, "x/cache/y")
+	assert.True(t, errors.Is(err, fs.ErrNotExist))
+}
@@ -0,0 +1,147 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+const helmIgnoreFileName = ".helmignore"
+
+/** The patterns that Helm applies before the `.helmignore` of a chart */
+const helmDefaultIgnore = "templates/.?*\n"
+
+/** The source of the default patterns of Helm */
+const helmDefaultSource = "(helm defaults)"
+
+/**
+ * Parses the content of a Helm `.helmignore` file into gitignore patterns. Like Helm, the lines are trimmed, and the
+ * rules follow `path.Match`: a rule without `/` matches the name of a path at any level, a rule with `/` matches the
+ * whole path relative to the chart, and a trailing `/` only matches the directories. The classes of `path.Match` are
+ * converted (e.g. `[^a]` becomes `[!a]`, and `[!a]` matches `!` or `a`).
+ *
+ * NOTE: Helm rejects `**`, and a negation ignores all the paths that do not match it, whatever the other rules. These
+ * rules cannot be expressed by the gitignore patterns, so they are reported and skipped.
+ *
+ * @param {string} helmIgnoreContent The content of the helmignore file
+ * @returns {([]Pattern, error)} The patterns in the order of the file, and an error that joins a `*ParseError` for each
+ *   rule that Helm rejects or whose meaning differs from git (`ErrUnsupportedPattern`), which is skipped
+ */
+func ParseHelmIgnore(helmIgnoreContent string) ([]Pattern, error) {
+	return parseHelmIgnore(helmIgnoreContent, "")
+}
+
+/** Like `ParseHelmIgnore`, but the source is copied to the patterns and to the errors */
+func parseHelmIgnore(helmIgnoreContent string, source string) ([]Pattern, error) {
+	patterns := []Pattern{}
+	errs := []error{}
+	lines := strings.Split(strings.TrimPrefix(helmIgnoreContent, byteOrderMark), "\n")
+	for iLine := range lines {
+		rule := strings.TrimSpace(lines[iLine])
+		if rule == "" || strings.HasPrefix(rule, "#") {
+			continue
+		}
+		pattern, ok, err := convertHelmRule(rule)
+		if err != nil {
+			errs = append(errs, &ParseError{Source: source, Line: iLine + 1, Pattern: rule, Err: err})
+			continue
+		}
+		if !ok {
+			continue
+		}
+		pattern.Source = source
+		pattern.Line = iLine + 1
+		patterns = append(patterns, pattern)
+	}
+	return patterns, errors.Join(errs...)
+}
+
+/**
+ * Converts a rule of a `.helmignore`
+ *
+ * @param {string} rule The trimmed rule
+ * @returns {(Pattern, bool, error)} The pattern, false if the rule can never match (e.g. `/`), and the error if it is
+ *   not supported
+ */
+func convertHelmRule(rule string) (Pattern, bool, error) {
+	if strings.Contains(rule, "**") {
+		return Pattern{}, false, fmt.Errorf("%w: Helm does not support **", ErrUnsupportedPattern)
+	}
+	if strings.HasPrefix(rule, "!") {
+		return Pattern{}, false, fmt.Errorf("%w: Helm ignores the paths that do not match a negation", ErrUnsupportedPattern)
+	}
+	dirOnly := strings.HasSuffix(rule, "/")
+	rule = strings.TrimSuffix(rule, "/")
+	if _, err := path.Match(rule, "abc"); err != nil {
+		return Pattern{}, false, err
+	}
+
+	line := helmGlob(rule)
+	if dirOnly {
+		line += "/"
+	}
+	pattern, ok := ParseGitIgnorePattern(line)
+	return pattern, ok, nil
+}
+
+/** Converts a valid glob of `path.Match` to a gitignore pattern */
+func helmGlob(glob string) string {
+	var builder strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch glob[i] {
+		case '\\':
+			builder.WriteString(glob[i : i+2])
+			i++
+		case '[':
+			builder.WriteByte('[')
+			i++
+			if glob[i] == '^' {
+				builder.WriteByte('!')
+				i++
+			} else if glob[i] == '!' {
+				builder.WriteString("\\!")
+				i++
+			}
+			for glob[i] != ']' {
+				switch glob[i] {
+				case '\\':
+					builder.WriteString(glob[i : i+2])
+					i++
+				case '[':
+					// `[:` would start a POSIX class of git
+					builder.WriteString("\\[")
+				default:
+					builder.WriteByte(glob[i])
+				}
+				i++
+			}
+			builder.WriteByte(']')
+		default:
+			builder.WriteByte(glob[i])
+		}
+	}
+	return builder.String()
+}
+
+/**
+ * Reads the patterns of a Helm chart: the default pattern of Helm (`templates/.?*`), followed by the ones of the
+ * `.helmignore` of the chart if it has one (see `ParseHelmIgnore`).
+ *
+ * @param {fs.FS} fsys The file system
+ * @param {string} chart The directory of the chart. The patterns are relative to it.
+ * @returns {([]Pattern, error)} The patterns, and the errors of `ParseHelmIgnore` or of reading the `.helmignore`
+ */
+func ReadHelmIgnore(fsys fs.FS, chart string) ([]Pattern, error) {
+	patterns, _ := parseHelmIgnore(helmDefaultIgnore, helmDefaultSource)
+	content, err := fs.ReadFile(fsys, path.Join(chart, helmIgnoreFileName))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return patterns, nil
+		}
+		return nil, err
+	}
+	filePatterns, err := parseHelmIgnore(string(content), helmIgnoreFileName)
+	return append(patterns, filePatterns...), err
+}
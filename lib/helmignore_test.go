@@ -0,0 +1,86 @@
+package lib
+
+import (
+	"errors"
+	"path"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseHelmIgnore(t *testing.T) {
+	cases := []struct {
+		helmIgnore string
+		expected   []string
+	}{
+		{"*.tgz\n  .git/  \n# comment\n", []string{"*.tgz", ".git/"}},
+		{"/secrets.yaml\ntemplates/*.tpl\nci/\n", []string{"/secrets.yaml", "templates/*.tpl", "ci/"}},
+		{"[^a]*\n[!a]\n[[:digit:]]\n", []string{"[!a]*", "[\\!a]", "[\\[:digit:]]"}},
+		{"\\#x\na\\*\n", []string{"\\#x", "a\\*"}},
+		{"/\n", []string{}},
+	}
+	for _, testCase := range cases {
+		patterns, err := ParseHelmIgnore(testCase.helmIgnore)
+		assert.Nil(t, err, testCase.helmIgnore)
+		assert.Equal(t, patternStrings(patterns), testCase.expected, testCase.helmIgnore)
+	}
+}
+
+func TestParseHelmIgnoreErrors(t *testing.T) {
+	patterns, err := ParseHelmIgnore("ok\n**/x\n!keep\n[a\n[]x]\n")
+	assert.Equal(t, patternStrings(patterns), []string{"ok"})
+	assert.True(t, errors.Is(err, ErrUnsupportedPattern))
+	assert.True(t, errors.Is(err, path.ErrBadPattern))
+	assert.Equal(t, strings.Split(err.Error(), "\n"), []string{
+		`line 2: "**/x": unsupported pattern: Helm does not support **`,
+		`line 3: "!keep": unsupported pattern: Helm ignores the paths that do not match a negation`,
+		`line 4: "[a": syntax error in pattern`,
+		`line 5: "[]x]": syntax error in pattern`,
+	})
+}
+
+/** The matcher answers like the rules of Helm, which use path.Match */
+func TestHelmIgnoreMatch(t *testing.T) {
+	patterns, err := ParseHelmIgnore("*.tgz\n/top.txt\ntemplates/*.tpl\nci/\n[^a]?.md\n")
+	assert.Nil(t, err)
+	matcher := NewMatcher(patterns)
+	cases := []struct {
+		name     string
+		isDir    bool
+		expected bool
+	}{
+		{"a.tgz", false, true},
+		{"charts/sub/a.tgz", false, true},
+		{"top.txt", false, true},
+		{"sub/top.txt", false, false},
+		{"templates/a.tpl", false, true},
+		{"templates/sub/a.tpl", false, false},
+		{"ci", true, true},
+		{"ci/values.yaml", false, true},
+		{"ci", false, false},
+		{"bb.md", false, true},
+		{"ab.md", false, false},
+	}
+	for _, testCase := range cases {
+		assert.Equal(t, matcher.Ignored(testCase.name, testCase.isDir), testCase.expected, testCase.name)
+	}
+}
+
+func TestReadHelmIgnore(t *testing.T) {
+	patterns, err := ReadHelmIgnore(fstest.MapFS{"chart/Chart.yaml": {}}, "chart")
+	assert.Nil(t, err)
+	assert.Equal(t, patternStrings(patterns), []string{"templates/.?*"})
+	assert.Equal(t, patterns[0].Source, helmDefaultSource)
+
+	patterns, err = ReadHelmIgnore(fstest.MapFS{"chart/.helmignore": {Data: []byte("*.tgz\n**/x\n")}}, "chart")
+	assert.Equal(t, patternStrings(patterns), []string{"templates/.?*", "*.tgz"})
+	assert.Equal(t, patterns[1].Source, ".helmignore")
+	assert.Equal(t, patterns[1].Line, 1)
+	assert.Equal(t, err.Error(), `.helmignore: line 2: "**/x": unsupported pattern: Helm does not support **`)
+
+	matcher := NewMatcher(patterns)
+	assert.True(t, matcher.Ignored("templates/.hidden.yaml", false))
+	assert.False(t, matcher.Ignored("templates/deployment.yaml", false))
+}
@@ -0,0 +1,807 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp/syntax"
+	"strings"
+	"unicode"
+)
+
+/**
+ * The syntaxes of the `.hgignore` patterns by the names of `syntax:` and of the line prefixes (e.g. `re:`). Like
+ * Mercurial, the patterns are regexps until the first `syntax:` line.
+ */
+var hgIgnoreSyntaxes = map[string]string{
+	"re":         "relre",
+	"regexp":     "relre",
+	"relre":      "relre",
+	"glob":       "relglob",
+	"relglob":    "relglob",
+	"rootglob":   "rootglob",
+	"include":    "include",
+	"subinclude": "subinclude",
+}
+
+/** The most gitignore patterns a `.hgignore` pattern is expanded to (e.g. for `{a,b}` or `a|b`) */
+const maxHgAlternatives = 64
+
+/**
+ * Parses the content of a Mercurial `.hgignore` file into gitignore patterns. The `syntax: glob`, `syntax: regexp` and
+ * `syntax: rootglob` sections, and the `glob:`, `re:` and `rootglob:` prefixes are supported. A glob with groups (e.g.
+ * `*.{c,h}`) becomes one pattern per alternative. The regexps are converted when they are made of literals, classes,
+ * `.`, `.*`, `[^/]*`, groups and alternatives (e.g. `^build/` or `\.py[co]$`).
+ *
+ * NOTE: the `?` and the `.` of Mercurial also match a `/`, but the converted `?` does not. Mercurial never matches a
+ * glob that ends with `/`, but it is converted to the directory-only pattern that was meant.
+ *
+ * @param {string} hgIgnoreContent The content of the hgignore file
+ * @returns {([]Pattern, error)} The patterns in the order of the file, and an error that joins a `*ParseError` for each
+ *   pattern that cannot be converted (e.g. `include:` or a regexp with a lookahead), which is skipped
+ */
+func ParseHgIgnore(hgIgnoreContent string) ([]Pattern, error) {
+	patterns := []Pattern{}
+	errs := []error{}
+	syntaxName := "relre"
+	lines := strings.Split(strings.TrimPrefix(hgIgnoreContent, byteOrderMark), "\n")
+	for iLine := range lines {
+		line := stripHgComment(lines[iLine])
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "syntax:") {
+			name := strings.TrimSpace(line[len("syntax:"):])
+			if lineSyntax, ok := hgIgnoreSyntaxes[name]; ok {
+				syntaxName = lineSyntax
+			} else {
+				errs = append(errs, &ParseError{Line: iLine + 1, Pattern: line, Err: fmt.Errorf("unknown syntax %q", name)})
+			}
+			continue
+		}
+
+		lineSyntax, text := syntaxName, line
+		for name, prefixSyntax := range hgIgnoreSyntaxes {
+			if strings.HasPrefix(line, name+":") {
+				lineSyntax, text = prefixSyntax, line[len(name)+1:]
+				break
+			}
+		}
+
+		var linePatterns []Pattern
+		var err error
+		switch lineSyntax {
+		case "relre":
+			linePatterns, err = convertHgRegexp(text)
+		case "relglob", "rootglob":
+			linePatterns, err = convertHgGlob(text, lineSyntax == "rootglob")
+		default:
+			// the other files are not read
+			err = fmt.Errorf("%w %s", ErrUnsupportedPattern, lineSyntax)
+		}
+		if err != nil {
+			errs = append(errs, &ParseError{Line: iLine + 1, Pattern: line, Err: err})
+			continue
+		}
+		for _, pattern := range linePatterns {
+			pattern.Line = iLine + 1
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns, errors.Join(errs...)
+}
+
+/**
+ * Like Mercurial, removes the comment of a line (a `#` that is not escaped by `\`), the escapes of `\#`, and the
+ * trailing whitespace
+ */
+func stripHgComment(line string) string {
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' {
+			i++
+		} else if line[i] == '#' {
+			line = line[:i]
+			break
+		}
+	}
+	return strings.TrimRightFunc(strings.ReplaceAll(line, `\#`, "#"), unicode.IsSpace)
+}
+
+/**
+ * Globify the content of a `.hgignore` file. The patterns of `ParseHgIgnore` are converted like the ones of
+ * `GlobifyGitIgnoreWithOptions`.
+ *
+ * @param {string} hgIgnoreContent The content of the hgignore file
+ * @param {GlobifyOptions} options The options of the conversion. `Strict` is ignored.
+ * @returns {([]string, error)} An array of glob patterns, and the errors of `ParseHgIgnore` and of
+ *   `GlobifyGitIgnoreWithOptions`
+ */
+func GlobifyHgIgnore(hgIgnoreContent string, options GlobifyOptions) ([]string, error) {
+	patterns, parseErr := ParseHgIgnore(hgIgnoreContent)
+	lines := make([]string, len(patterns))
+	for iPattern, pattern := range patterns {
+		lines[iPattern] = pattern.String()
+	}
+	options.Strict = true
+	globs, err := GlobifyGitIgnoreWithOptions(strings.Join(lines, "\n"), options)
+	if globs == nil {
+		return nil, err
+	}
+	return globs, errors.Join(parseErr, err)
+}
+
+/** The strings that only exist while the patterns are converted. They are not valid in the patterns. */
+const (
+	/** Any string, including `/` (e.g. `.*` or `**` in the middle of a name) */
+	hgAnyString = "\x00"
+	/** The `^` of a regexp */
+	hgBeginText = "\x01"
+	/** The `$` of a regexp */
+	hgEndText = "\x02"
+)
+
+/** Converts a Mercurial glob. A relative glob matches at any level like an unanchored gitignore pattern. */
+func convertHgGlob(glob string, rooted bool) ([]Pattern, error) {
+	dirOnly := strings.HasSuffix(glob, "/")
+	glob = strings.TrimRight(glob, "/")
+	alternatives, _, err := hgGlobAlternatives(glob, 0, false)
+	if err != nil {
+		return nil, err
+	}
+	if !rooted {
+		for iAlternative, alternative := range alternatives {
+			alternatives[iAlternative] = hgAnyString + "/" + alternative
+		}
+	}
+	return hgPatterns(alternatives, dirOnly)
+}
+
+/**
+ * Converts a Mercurial glob from the given index to gitignore patterns. In a group, it stops at the `,` or the `}` that
+ * ends the alternative.
+ */
+func hgGlobAlternatives(glob string, start int, inGroup bool) ([]string, int, error) {
+	alternatives := []string{""}
+	var err error
+	i := start
+	for ; i < len(glob); i++ {
+		ch := glob[i]
+		var fragments []string
+		switch {
+		case ch == '\\':
+			if i+1 == len(glob) {
+				fragments = []string{`\\`}
+				break
+			}
+			i++
+			fragments = []string{gitIgnoreEscape(glob[i : i+1])}
+		case ch == '*' && charAt(glob, i+1) == '*':
+			i++
+			if charAt(glob, i+1) == '/' {
+				// `**/` matches any directories, including none
+				i++
+				if i == 2 || glob[i-3] == '/' {
+					fragments = []string{"**/"}
+				} else {
+					fragments = []string{"", hgAnyString + "/"}
+				}
+			} else {
+				fragments = []string{hgAnyString}
+			}
+		case ch == '*', ch == '?':
+			fragments = []string{glob[i : i+1]}
+		case ch == '[':
+			end := i + 1
+			if charAt(glob, end) == '!' || charAt(glob, end) == ']' {
+				end++
+			}
+			for end < len(glob) && glob[end] != ']' {
+				end++
+			}
+			if end == len(glob) {
+				fragments = []string{`\[`}
+				break
+			}
+			fragments = []string{hgGlobClass(glob[i+1 : end])}
+			i = end
+		case ch == '{':
+			fragments = []string{}
+			for glob[i] != '}' {
+				var groupAlternatives []string
+				groupAlternatives, i, err = hgGlobAlternatives(glob, i+1, true)
+				if err != nil {
+					return nil, i, err
+				}
+				if i == len(glob) {
+					return nil, i, fmt.Errorf("%w: unterminated `{`", ErrUnsupportedPattern)
+				}
+				fragments = append(fragments, groupAlternatives...)
+			}
+		case inGroup && (ch == ',' || ch == '}'):
+			return alternatives, i, nil
+		default:
+			fragments = []string{gitIgnoreEscape(glob[i : i+1])}
+		}
+		if alternatives, err = concatAlternatives(alternatives, fragments); err != nil {
+			return nil, i, err
+		}
+	}
+	return alternatives, i, nil
+}
+
+/** Converts the content of a class of a Mercurial glob. Like Mercurial, `!` negates the class and `\` is literal. */
+func hgGlobClass(content string) string {
+	var builder strings.Builder
+	builder.WriteByte('[')
+	for i := 0; i < len(content); i++ {
+		ch := content[i]
+		switch {
+		case i == 0 && ch == '!':
+			builder.WriteByte('!')
+		case ch == '\\', ch == '[', i == 0 && ch == '^':
+			builder.WriteByte('\\')
+			builder.WriteByte(ch)
+		default:
+			builder.WriteByte(ch)
+		}
+	}
+	builder.WriteByte(']')
+	return builder.String()
+}
+
+/**
+ * Converts a Mercurial regexp. Like Mercurial, it matches from the start of the path if it starts with `^`, and
+ * anywhere otherwise. It matches the paths that start with its matches, so a `$` is only needed to reject the longer
+ * names.
+ */
+func convertHgRegexp(expression string) ([]Pattern, error) {
+	parsed, err := syntax.Parse(expression, syntax.Perl)
+	if err != nil {
+		return nil, err
+	}
+	alternatives, err := regexpAlternatives(parsed)
+	if err != nil {
+		return nil, err
+	}
+	for iAlternative, alternative := range alternatives {
+		if strings.HasPrefix(alternative, hgBeginText) {
+			alternative = alternative[len(hgBeginText):]
+		} else {
+			alternative = hgAnyString + alternative
+		}
+		if strings.HasSuffix(alternative, hgEndText) {
+			alternative = alternative[:len(alternative)-len(hgEndText)]
+		} else {
+			alternative += hgAnyString
+		}
+		if strings.Contains(alternative, hgBeginText) || strings.Contains(alternative, hgEndText) {
+			return nil, fmt.Errorf("%w: `^` or `$` in the middle of the regexp", ErrUnsupportedPattern)
+		}
+		alternatives[iAlternative] = alternative
+	}
+	return hgPatterns(alternatives, false)
+}
+
+/** Converts a parsed regexp to the gitignore patterns of its alternatives */
+func regexpAlternatives(parsed *syntax.Regexp) ([]string, error) {
+	if parsed.Flags&syntax.FoldCase != 0 {
+		return nil, fmt.Errorf("%w: case-insensitive regexp", ErrUnsupportedPattern)
+	}
+	switch parsed.Op {
+	case syntax.OpNoMatch:
+		return []string{}, nil
+	case syntax.OpEmptyMatch:
+		return []string{""}, nil
+	case syntax.OpLiteral:
+		return []string{gitIgnoreEscape(string(parsed.Rune))}, nil
+	case syntax.OpCharClass:
+		return []string{regexpClassGlob(parsed.Rune)}, nil
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return []string{"?"}, nil
+	case syntax.OpBeginLine, syntax.OpBeginText:
+		return []string{hgBeginText}, nil
+	case syntax.OpEndLine, syntax.OpEndText:
+		return []string{hgEndText}, nil
+	case syntax.OpCapture:
+		return regexpAlternatives(parsed.Sub[0])
+	case syntax.OpStar:
+		return regexpStar(parsed.Sub[0])
+	case syntax.OpPlus, syntax.OpRepeat:
+		minimum, maximum := 1, -1
+		if parsed.Op == syntax.OpRepeat {
+			minimum, maximum = parsed.Min, parsed.Max
+		}
+		one, err := regexpAlternatives(parsed.Sub[0])
+		if err != nil {
+			return nil, err
+		}
+		alternatives := []string{""}
+		for i := 0; i < minimum; i++ {
+			if alternatives, err = concatAlternatives(alternatives, one); err != nil {
+				return nil, err
+			}
+		}
+		rest := []string{""}
+		if maximum == -1 {
+			rest, err = regexpStar(parsed.Sub[0])
+		}
+		for i := minimum; i < maximum && err == nil; i++ {
+			rest, err = concatAlternatives(rest, append([]string{""}, one...))
+		}
+		if err != nil {
+			return nil, err
+		}
+		return concatAlternatives(alternatives, unique(rest))
+	case syntax.OpQuest:
+		alternatives, err := regexpAlternatives(parsed.Sub[0])
+		if err != nil {
+			return nil, err
+		}
+		return unique(append([]string{""}, alternatives...)), nil
+	case syntax.OpConcat:
+		alternatives := []string{""}
+		for _, sub := range parsed.Sub {
+			subAlternatives, err := regexpAlternatives(sub)
+			if err != nil {
+				return nil, err
+			}
+			if alternatives, err = concatAlternatives(alternatives, subAlternatives); err != nil {
+				return nil, err
+			}
+		}
+		return alternatives, nil
+	case syntax.OpAlternate:
+		alternatives := []string{}
+		for _, sub := range parsed.Sub {
+			subAlternatives, err := regexpAlternatives(sub)
+			if err != nil {
+				return nil, err
+			}
+			alternatives = append(alternatives, subAlternatives...)
+		}
+		if len(alternatives) > maxHgAlternatives {
+			return nil, fmt.Errorf("%w: more than %d alternatives", ErrUnsupportedPattern, maxHgAlternatives)
+		}
+		return unique(alternatives), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedPattern, parsed)
+	}
+}
+
+/** Converts the repetition of a single character (e.g. `.*` or `[^/]*`) */
+func regexpStar(sub *syntax.Regexp) ([]string, error) {
+	switch sub.Op {
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return []string{hgAnyString}, nil
+	case syntax.OpCharClass:
+		if regexpClassMatches(sub.Rune, '/') {
+			return []string{hgAnyString}, nil
+		}
+		if regexpClassGlob(sub.Rune) == "?" {
+			return []string{"*"}, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedPattern, &syntax.Regexp{Op: syntax.OpStar, Sub: []*syntax.Regexp{sub}})
+}
+
+/** Does the class (as ranges of runes) match the rune? */
+func regexpClassMatches(ranges []rune, ch rune) bool {
+	for i := 0; i+1 < len(ranges); i += 2 {
+		if ranges[i] <= ch && ch <= ranges[i+1] {
+			return true
+		}
+	}
+	return false
+}
+
+/**
+ * Converts a class (as ranges of runes) to a gitignore class. A class that matches all the characters but `/` (or the
+ * new line) is `?`.
+ */
+func regexpClassGlob(ranges []rune) string {
+	negated := len(ranges) != 0 && ranges[0] == 0 && ranges[len(ranges)-1] == unicode.MaxRune
+	if negated {
+		// the complement
+		complement := []rune{}
+		for i := 1; i+1 < len(ranges); i += 2 {
+			complement = append(complement, ranges[i]+1, ranges[i+1]-1)
+		}
+		ranges = complement
+		if len(ranges) == 0 || (len(ranges) == 2 && ranges[0] == ranges[1] && (ranges[0] == '/' || ranges[0] == '\n')) {
+			return "?"
+		}
+	}
+	if len(ranges) == 2 && ranges[0] == ranges[1] && !negated {
+		return gitIgnoreEscape(string(ranges[0]))
+	}
+
+	var builder strings.Builder
+	builder.WriteByte('[')
+	if negated {
+		builder.WriteByte('!')
+	}
+	for i := 0; i+1 < len(ranges); i += 2 {
+		writeClassRune(&builder, ranges[i])
+		if ranges[i+1] != ranges[i] {
+			builder.WriteByte('-')
+			writeClassRune(&builder, ranges[i+1])
+		}
+	}
+	builder.WriteByte(']')
+	return builder.String()
+}
+
+func writeClassRune(builder *strings.Builder, ch rune) {
+	if ch < 0x80 && isClassSpecial(byte(ch)) {
+		builder.WriteByte('\\')
+	}
+	builder.WriteRune(ch)
+}
+
+/** The alternatives of the concatenations of the alternatives */
+func concatAlternatives(alternatives []string, fragments []string) ([]string, error) {
+	if len(alternatives)*len(fragments) > maxHgAlternatives {
+		return nil, fmt.Errorf("%w: more than %d alternatives", ErrUnsupportedPattern, maxHgAlternatives)
+	}
+	concatenated := make([]string, 0, len(alternatives)*len(fragments))
+	for _, alternative := range alternatives {
+		for _, fragment := range fragments {
+			concatenated = append(concatenated, alternative+fragment)
+		}
+	}
+	return concatenated, nil
+}
+
+/** Escapes the characters that are special in the gitignore patterns */
+func gitIgnoreEscape(literal string) string {
+	var builder strings.Builder
+	for i := 0; i < len(literal); i++ {
+		if strings.IndexByte("\\*?[", literal[i]) != -1 {
+			builder.WriteByte('\\')
+		}
+		builder.WriteByte(literal[i])
+	}
+	return builder.String()
+}
+
+/**
+ * Converts the alternatives of a Mercurial pattern, which are relative to the root, to gitignore patterns. The
+ * `hgAnyString` are replaced by the gitignore globs that match the same paths.
+ */
+func hgPatterns(alternatives []string, dirOnly bool) ([]Pattern, error) {
+	texts := []string{}
+	for _, alternative := range alternatives {
+		resolved, err := resolveHgAnyString(alternative)
+		if err != nil {
+			return nil, err
+		}
+		texts = append(texts, resolved...)
+	}
+
+	patterns := []Pattern{}
+	for _, text := range unique(texts) {
+		text = CanonicalGlob(strings.TrimPrefix(text, "/"))
+		if rest := strings.TrimPrefix(text, "**/"); rest != text && !strings.Contains(rest, "/") {
+			// `**/name` is the same as an unanchored `name`
+			text = rest
+		} else if !strings.Contains(text, "/") {
+			text = "/" + text
+		}
+		if strings.HasPrefix(text, "!") || strings.HasPrefix(text, "#") {
+			text = `\` + text
+		}
+		if strings.HasSuffix(text, " ") {
+			text = text[:len(text)-1] + `\ `
+		}
+		if err := CheckGitIgnorePattern(text); err != nil {
+			return nil, err
+		}
+		entry := text
+		if dirOnly {
+			entry += "/"
+		}
+		if pattern, ok := ParseGitIgnorePattern(entry); ok {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns, nil
+}
+
+/**
+ * Replaces the `hgAnyString` of a pattern (e.g. `a` + any + `b` becomes `a*b` and `a*` + `/**` + `/*b`). It is `**` as
+ * a whole segment, and it also matches the rest of a name at its start or its end.
+ */
+func resolveHgAnyString(text string) ([]string, error) {
+	index := strings.Index(text, hgAnyString)
+	if index == -1 {
+		return []string{text}, nil
+	}
+	end := index
+	for end < len(text) && text[end:end+1] == hgAnyString {
+		end++
+	}
+	before, after := text[:index], text[end:]
+	atStart := before == "" || strings.HasSuffix(before, "/")
+	atEnd := after == "" || strings.HasPrefix(after, "/")
+	var replacements []string
+	switch {
+	case atStart && atEnd:
+		replacements = []string{"**"}
+	case atStart:
+		replacements = []string{"**/*"}
+	case atEnd && after == "":
+		// the matched names also match the paths below them
+		replacements = []string{"*"}
+	case atEnd:
+		replacements = []string{"*/**"}
+	default:
+		replacements = []string{"*", "*/**/*"}
+	}
+
+	resolved := []string{}
+	for _, replacement := range replacements {
+		rest, err := resolveHgAnyString(after)
+		if err != nil {
+			return nil, err
+		}
+		for _, restText := range rest {
+			resolved = append(resolved, before+replacement+restText)
+		}
+	}
+	if len(resolved) > maxHgAlternatives {
+		return nil, fmt.Errorf("%w: more than %d alternatives", ErrUnsupportedPattern, maxHgAlternatives)
+	}
+	return resolved, nil
+}
+
+/**
+ * Converts gitignore patterns to a Mercurial `.hgignore` file that ignores the same files. The patterns are written as
+ * globs when Mercurial globs can express them, and as regexps otherwise: a directory-only pattern matches the paths
+ * below the directory, and a pattern followed by negated patterns rejects them with a lookahead (e.g.
+ * `re:^(?!(?:.*\/)?keep\.log$)(?:.*\/)?[^/]*\.log$` for `*.log` and `!keep.log`). The negated patterns are not written.
+ *
+ * NOTE: Mercurial cannot tell the files from the directories, so a directory-only pattern that is followed by a negated
+ * pattern also matches the files with the same name, and a directory-only negated pattern also re-includes them.
+ *
+ * @param {[]Pattern} patterns The patterns (e.g. from `ParseGitIgnore`). Their `Base` is relative to the root of the
+ *   Mercurial repository.
+ * @returns {(string, error)} The content of the hgignore file, and an error that joins a `*ParseError` for each
+ *   malformed pattern (which is skipped)
+ */
+func RenderHgIgnore(patterns []Pattern) (string, error) {
+	valid := make([]Pattern, 0, len(patterns))
+	errs := []error{}
+	for _, pattern := range patterns {
+		if err := checkEntry(pattern.String()); err != nil {
+			err.Source = pattern.Source
+			err.Line = pattern.Line
+			errs = append(errs, err)
+			continue
+		}
+		valid = append(valid, pattern)
+	}
+
+	var builder strings.Builder
+	builder.WriteString("syntax: glob\n")
+	for iPattern, pattern := range valid {
+		if pattern.Negated {
+			continue
+		}
+		negations := []Pattern{}
+		for _, later := range valid[iPattern+1:] {
+			if later.Negated && !disjointPatterns(pattern, later) {
+				negations = append(negations, later)
+			}
+		}
+		builder.WriteString(hgIgnoreLine(pattern, negations))
+		builder.WriteByte('\n')
+	}
+	return builder.String(), errors.Join(errs...)
+}
+
+/** The line of `.hgignore` for a pattern */
+func hgIgnoreLine(pattern Pattern, negations []Pattern) string {
+	if len(negations) == 0 {
+		if pattern.DirOnly {
+			// the files below the directory
+			return "re:^" + hgRegexp(pattern) + "/"
+		}
+		if glob, ok := hgGlob(pattern); ok {
+			return glob
+		}
+		return "re:^" + hgRegexp(pattern) + "(?:/|$)"
+	}
+	rejected := make([]string, len(negations))
+	for iNegation, negation := range negations {
+		rejected[iNegation] = hgRegexp(negation) + "$"
+	}
+	return "re:^(?!" + strings.Join(rejected, "|") + ")" + hgRegexp(pattern) + "$"
+}
+
+/**
+ * Can the patterns never match the same path? It is the case if the last segment of one of them is a literal name that
+ * the last segment of the other does not match (e.g. `*.log` and `!keep.txt`).
+ */
+func disjointPatterns(pattern Pattern, other Pattern) bool {
+	name, otherName := path.Base(pattern.Text), path.Base(other.Text)
+	switch {
+	case !strings.ContainsAny(otherName, "\\*?["):
+		return !Wildmatch(name, otherName, 0)
+	case !strings.ContainsAny(name, "\\*?["):
+		return !Wildmatch(otherName, name, 0)
+	}
+	return false
+}
+
+/** Converts a pattern to a Mercurial glob line if the globs can express it exactly */
+func hgGlob(pattern Pattern) (string, bool) {
+	text := strings.TrimPrefix(pattern.Text, "/")
+	var builder strings.Builder
+	for i := 0; i < len(text); i++ {
+		ch := text[i]
+		switch {
+		case ch == '\\':
+			i++
+			if text[i] == ' ' && i+1 == len(text) {
+				// Mercurial removes the trailing whitespace
+				builder.WriteString("[ ]")
+			} else {
+				builder.WriteString(hgGlobEscape(text[i : i+1]))
+			}
+		case ch == '*':
+			start := i
+			for charAt(text, i+1) == '*' {
+				i++
+			}
+			if i != start && (start == 0 || text[start-1] == '/') && (i+1 == len(text) || text[i+1] == '/') {
+				builder.WriteString("**")
+			} else {
+				builder.WriteByte('*')
+			}
+		case ch == '?':
+			builder.WriteString("[!/]")
+		case ch == '[':
+			end, _ := characterClassEnd(text, i)
+			class := regexpCharacterClass(text[i : end+1])
+			if strings.ContainsRune(class, '\\') {
+				// the classes of the globs cannot escape
+				return "", false
+			}
+			if strings.HasPrefix(class, "[^") {
+				class = "[!" + class[2:]
+			}
+			builder.WriteString(class)
+			i = end
+		default:
+			builder.WriteString(hgGlobEscape(text[i : i+1]))
+		}
+	}
+	glob := builder.String()
+
+	base := hgGlobEscape(pattern.Base)
+	switch {
+	case pattern.Anchored && base != "":
+		return "rootglob:" + base + "/" + glob, true
+	case pattern.Anchored:
+		return "rootglob:" + glob, true
+	case base != "":
+		return "rootglob:" + base + "/**/" + glob, true
+	case strings.Contains(glob, ":") || strings.HasPrefix(glob, " "):
+		// the glob could be read as a prefix
+		return "glob:" + glob, true
+	default:
+		return glob, true
+	}
+}
+
+/** Escapes the characters that are special in the globs of Mercurial or in `.hgignore` */
+func hgGlobEscape(literal string) string {
+	var builder strings.Builder
+	for i := 0; i < len(literal); i++ {
+		if strings.IndexByte("\\*?[]{},#", literal[i]) != -1 {
+			builder.WriteByte('\\')
+		}
+		builder.WriteByte(literal[i])
+	}
+	return builder.String()
+}
+
+/**
+ * Converts a pattern to a Python regexp that matches its paths from the start. The path of a matched directory does
+ * not end with `/`.
+ */
+func hgRegexp(pattern Pattern) string {
+	var builder strings.Builder
+	if pattern.Base != "" {
+		builder.WriteString(regexpEscape(pattern.Base))
+		builder.WriteByte('/')
+	}
+	text := pattern.Text
+	if !pattern.Anchored {
+		builder.WriteString("(?:.*/)?")
+	}
+	text = strings.TrimPrefix(text, "/")
+	for i := 0; i < len(text); i++ {
+		ch := text[i]
+		switch ch {
+		case '\\':
+			i++
+			builder.WriteString(regexpEscape(text[i : i+1]))
+		case '*':
+			start := i
+			for charAt(text, i+1) == '*' {
+				i++
+			}
+			switch {
+			case i == start || (start != 0 && text[start-1] != '/') || (i+1 != len(text) && text[i+1] != '/'):
+				builder.WriteString("[^/]*")
+			case i+1 == len(text):
+				builder.WriteString(".*")
+			default:
+				// `**/` matches any directories, including none
+				builder.WriteString("(?:.*/)?")
+				i++
+			}
+		case '?':
+			builder.WriteString("[^/]")
+		case '[':
+			end, _ := characterClassEnd(text, i)
+			builder.WriteString(regexpCharacterClass(text[i : end+1]))
+			i = end
+		default:
+			builder.WriteString(regexpEscape(text[i : i+1]))
+		}
+	}
+	return builder.String()
+}
+
+/** Escapes the characters that are special in the Python regexps or in `.hgignore` */
+func regexpEscape(literal string) string {
+	var builder strings.Builder
+	for i := 0; i < len(literal); i++ {
+		if strings.IndexByte("\\.^$*+?{}[]|()#", literal[i]) != -1 {
+			builder.WriteByte('\\')
+		}
+		builder.WriteByte(literal[i])
+	}
+	return builder.String()
+}
+
+/** Converts a well-formed gitignore class (including its brackets) to a Python class. Like `?`, it does not match `/`. */
+func regexpCharacterClass(class string) string {
+	var builder strings.Builder
+	builder.WriteByte('[')
+	i := 1
+	if class[i] == '!' || class[i] == '^' {
+		builder.WriteString("^/")
+		i++
+	}
+	for first := true; i < len(class)-1; i, first = i+1, false {
+		ch := class[i]
+		switch {
+		case ch == '\\':
+			i++
+			if isUpper(class[i]) || isLower(class[i]) || isDigit(class[i]) {
+				// `\d` is a class in Python
+				builder.WriteByte(class[i])
+			} else {
+				builder.WriteString(class[i-1 : i+1])
+			}
+		case ch == '[' && class[i+1] == ':':
+			nameEnd := i + 2 + strings.IndexByte(class[i+2:], ']')
+			if class[nameEnd-1] != ':' || nameEnd-1 < i+2 {
+				builder.WriteString("\\[")
+				continue
+			}
+			builder.WriteString(posixClassRanges[class[i+2:nameEnd-1]])
+			i = nameEnd
+		case ch == ']' && first, ch == '[', ch == '^', ch == '#':
+			builder.WriteByte('\\')
+			builder.WriteByte(ch)
+		default:
+			builder.WriteByte(ch)
+		}
+	}
+	builder.WriteByte(']')
+	return builder.String()
+}
@@ -0,0 +1,123 @@
+package lib
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/** The patterns in their gitignore form */
+func patternStrings(patterns []Pattern) []string {
+	strs := []string{}
+	for _, pattern := range patterns {
+		strs = append(strs, pattern.String())
+	}
+	return strs
+}
+
+func TestParseHgIgnore(t *testing.T) {
+	cases := []struct {
+		hgIgnore string
+		expected []string
+	}{
+		// the regexps are the default
+		{"\\.pyc$\n", []string{"*.pyc"}},
+		{"^build/\n", []string{"build/**"}},
+		{"^build$\n", []string{"/build"}},
+		{"foo\n", []string{"*foo*"}},
+		{"\\.py[co]$\n", []string{"*.py[co]"}},
+		{"\\.sw[op]$\n", []string{"*.sw[o-p]"}},
+		{"(?:^|/)node_modules/\n", []string{"node_modules/**", "**/node_modules/**"}},
+		{"^docs/.*\\.(md|txt)$\n", []string{"docs/**/*.md", "docs/**/*.txt"}},
+		{"^a[^/]*b$\n", []string{"/a*b"}},
+		{"^a.*b$\n", []string{"/a*b", "a*/**/*b"}},
+		{"^out/?$\n", []string{"/out", "out/"}},
+		{"re:^a\\#b$ # comment\n", []string{"/a#b"}},
+		// globs
+		{"syntax: glob\n*.elc\nsrc/*.tmp\nlog/\n", []string{"*.elc", "**/src/*.tmp", "log/"}},
+		{"syntax: glob\n*.{c,h}~\n", []string{"*.c~", "*.h~"}},
+		{"syntax: glob\nx**y\n", []string{"x*y", "**/x*/**/*y"}},
+		{"syntax: glob\n[!a]?\n[^a]\n", []string{"[!a]?", "[\\^a]"}},
+		{"syntax: glob\n\\{a\\}\n", []string{"{a}"}},
+		{"syntax: rootglob\ndist/*\n*.o\n**/tmp\n", []string{"dist/*", "/*.o", "tmp"}},
+		{"glob:*.o\nrootglob:*.a\nre:\\.so$\n", []string{"*.o", "/*.a", "*.so"}},
+		{"syntax: glob\n!x\n", []string{"\\!x"}},
+	}
+	for _, testCase := range cases {
+		patterns, err := ParseHgIgnore(testCase.hgIgnore)
+		assert.Nil(t, err, testCase.hgIgnore)
+		assert.Equal(t, patternStrings(patterns), testCase.expected, testCase.hgIgnore)
+	}
+}
+
+func TestParseHgIgnoreErrors(t *testing.T) {
+	patterns, err := ParseHgIgnore("syntax: perl\ninclude:other\n(?i)x\n\\bx\n(?=x)\n^a$b\na{1,1000}\n^ok$\n")
+	assert.Equal(t, patternStrings(patterns), []string{"/ok"})
+	assert.True(t, errors.Is(err, ErrUnsupportedPattern))
+	lines := strings.Split(err.Error(), "\n")
+	assert.Equal(t, len(lines), 7)
+	assert.Equal(t, lines[0], `line 1: "syntax: perl": unknown syntax "perl"`)
+	assert.Equal(t, lines[1], `line 2: "include:other": unsupported pattern include`)
+	assert.Equal(t, lines[4], "line 5: \"(?=x)\": error parsing regexp: invalid or unsupported Perl syntax: `(?=`")
+
+	var parseError *ParseError
+	assert.True(t, errors.As(err, &parseError))
+	assert.Equal(t, parseError.Line, 1)
+}
+
+func TestGlobifyHgIgnore(t *testing.T) {
+	globs, err := GlobifyHgIgnore("\\.pyc$\nsyntax: glob\nbuild/\n", GlobifyOptions{Directory: "root", Dialect: DialectDoublestar})
+	assert.Nil(t, err)
+	assert.Equal(t, globs, []string{"!root/**/*.pyc", "!root/**/*.pyc/*/**", "!root/**/build/*/**"})
+}
+
+func TestRenderHgIgnore(t *testing.T) {
+	cases := []struct {
+		gitIgnore string
+		expected  string
+	}{
+		{"*.o\n/dist\nsrc/**/gen\n", "*.o\nrootglob:dist\nrootglob:src/**/gen\n"},
+		{"foo?\n[[:digit:]]*.bak\n[!a]\n", "foo[!/]\n[0-9]*.bak\n[!/a]\n"},
+		{"{a},b\n#x\nc:d\n", "\\{a\\}\\,b\nglob:c:d\n"},
+		{"\\#x\ntrailing\\ \n", "\\#x\ntrailing[ ]\n"},
+		{"a**b\n**/x/**\n", "a*b\nrootglob:**/x/**\n"},
+		{"build/\n", "re:^(?:.*/)?build/\n"},
+		{"[\\]]x\n", "re:^(?:.*/)?[\\]]x(?:/|$)\n"},
+		{"*.log\n!keep.log\n", "re:^(?!(?:.*/)?keep\\.log$)(?:.*/)?[^/]*\\.log$\n"},
+		// the negations that cannot match the paths of a pattern are not needed
+		{"/dist\n*.log\n!keep.log\n", "rootglob:dist\nre:^(?!(?:.*/)?keep\\.log$)(?:.*/)?[^/]*\\.log$\n"},
+		// a negation only applies to the earlier patterns
+		{"!keep.log\n*.log\n", "*.log\n"},
+		{"/a/*\n!/a/b/\n", "re:^(?!a/b$)a/[^/]*$\n"},
+	}
+	for _, testCase := range cases {
+		hgIgnore, err := RenderHgIgnore(ParseGitIgnore(testCase.gitIgnore))
+		assert.Nil(t, err, testCase.gitIgnore)
+		assert.Equal(t, hgIgnore, "syntax: glob\n"+testCase.expected, testCase.gitIgnore)
+	}
+
+	hgIgnore, err := RenderHgIgnore(ParseGitIgnore("*.o\n", "sub/dir"))
+	assert.Nil(t, err)
+	assert.Equal(t, hgIgnore, "syntax: glob\nrootglob:sub/dir/**/*.o\n")
+	hgIgnore, err = RenderHgIgnore(ParseGitIgnore("ok\nfoo[\n"))
+	assert.Equal(t, hgIgnore, "syntax: glob\nok\n")
+	assert.True(t, errors.Is(err, ErrUnterminatedCharacterClass))
+}
+
+/** The globs of the rendered hgignore are parsed back to patterns that ignore the same paths */
+func TestRenderHgIgnoreRoundTrip(t *testing.T) {
+	gitIgnore := "*.o\n/dist\nsrc/**/gen\nfoo?\n[[:digit:]]*.bak\ndocs/*.md\n"
+	paths := []string{"a.o", "x/a.o", "dist", "x/dist", "src/gen", "src/a/b/gen", "gen", "foo1", "x/foo/", "1.bak", "docs/a.md", "x/docs/a.md"}
+	hgIgnore, err := RenderHgIgnore(ParseGitIgnore(gitIgnore))
+	assert.Nil(t, err)
+	patterns, err := ParseHgIgnore(hgIgnore)
+	assert.Nil(t, err)
+
+	matcher := NewMatcher(ParseGitIgnore(gitIgnore))
+	roundTrip := NewMatcher(patterns)
+	for _, name := range paths {
+		assert.Equal(t, roundTrip.Ignored(name, false), matcher.Ignored(name, false), name)
+	}
+}
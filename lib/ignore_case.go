@@ -0,0 +1,204 @@
+package lib
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/**
+ * Detects whether git compares the paths of a repository case-insensitively. Like git, `core.ignoreCase` of the
+ * config of the repository is used, and it is false if it is not set. The file system is not probed, as that writes a
+ * file into the repository (use `ProbeIgnoreCase` explicitly).
+ *
+ * @param {string} repository The working tree of the repository (the directory that has `.git`)
+ * @returns {(bool, error)} Whether the paths are compared case-insensitively
+ */
+func DetectIgnoreCase(repository string) (bool, error) {
+	ignoreCase, _, err := ReadGitIgnoreCase(filepath.Join(repository, ".git"))
+	return ignoreCase, err
+}
+
+/**
+ * Reads `core.ignoreCase` from the config of a git repository. The `include` directives are not followed.
+ *
+ * @param {string} gitDirectory The `.git` directory, or a `.git` file that points to it (e.g. in a worktree)
+ * @returns {(bool, bool, error)} The value, whether it is set, and the error of reading the config. A missing config
+ *   is not an error.
+ */
+func ReadGitIgnoreCase(gitDirectory string) (bool, bool, error) {
+	return readGitConfigBool(gitDirectory, "core", "ignorecase")
+}
+
+/** Reads a boolean variable from the config of a git repository (see `ReadGitIgnoreCase`) */
+func readGitConfigBool(gitDirectory string, section string, name string) (bool, bool, error) {
+	configDirectory, err := resolveGitDirectory(gitDirectory)
+	if err != nil {
+		return false, false, err
+	}
+	content, err := os.ReadFile(filepath.Join(configDirectory, "config"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, false, nil
+		}
+		return false, false, err
+	}
+	value, ok := gitConfigValue(string(content), section, name)
+	if !ok {
+		return false, false, nil
+	}
+	boolean, err := parseGitConfigBool(value, section+"."+name)
+	if err != nil {
+		return false, false, err
+	}
+	return boolean, true, nil
+}
+
+/** Finds the directory of the config: it follows a `.git` file and the `commondir` of a worktree */
+func resolveGitDirectory(gitDirectory string) (string, error) {
+	info, err := os.Stat(gitDirectory)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return gitDirectory, nil
+		}
+		return "", err
+	}
+	if !info.IsDir() {
+		content, err := os.ReadFile(gitDirectory)
+		if err != nil {
+			return "", err
+		}
+		target := strings.TrimSpace(string(content))
+		if !strings.HasPrefix(target, "gitdir:") {
+			return "", errors.New(gitDirectory + ": invalid gitfile format")
+		}
+		target = strings.TrimSpace(strings.TrimPrefix(target, "gitdir:"))
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(gitDirectory), target)
+		}
+		gitDirectory = target
+	}
+	commonDirectory, err := os.ReadFile(filepath.Join(gitDirectory, "commondir"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return gitDirectory, nil
+		}
+		return "", err
+	}
+	target := strings.TrimSpace(string(commonDirectory))
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(gitDirectory, target)
+	}
+	return target, nil
+}
+
+/**
+ * Finds the last value of a variable in the content of a git config file. The names of the sections and the variables
+ * are case-insensitive. The subsections (e.g. `[remote "origin"]`) are not matched.
+ */
+func gitConfigValue(content string, section string, name string) (string, bool) {
+	value, found := "", false
+	inSection := false
+	for rest := content; rest != ""; {
+		var line string
+		line, rest = cutLine(rest)
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			end := strings.IndexByte(line, ']')
+			if end == -1 {
+				inSection = false
+				continue
+			}
+			inSection = strings.EqualFold(strings.TrimSpace(line[1:end]), section)
+			// a variable can follow the header on the same line
+			line = strings.TrimSpace(line[end+1:])
+		}
+		if !inSection || line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		key, variableValue := line, "true"
+		if equal := strings.IndexByte(line, '='); equal != -1 {
+			key, variableValue = line[:equal], unquoteGitConfigValue(line[equal+1:])
+		}
+		if strings.EqualFold(strings.TrimSpace(key), name) {
+			value, found = variableValue, true
+		}
+	}
+	return value, found
+}
+
+/** Removes the comments, the surrounding whitespace and the quotes of a config value */
+func unquoteGitConfigValue(raw string) string {
+	var builder strings.Builder
+	quoted := false
+	for i := 0; i < len(raw); i++ {
+		ch := raw[i]
+		switch {
+		case ch == '"':
+			quoted = !quoted
+		case ch == '\\' && i+1 < len(raw):
+			i++
+			switch raw[i] {
+			case 'n':
+				builder.WriteByte('\n')
+			case 't':
+				builder.WriteByte('\t')
+			case 'b':
+				builder.WriteByte('\b')
+			default:
+				builder.WriteByte(raw[i])
+			}
+		case (ch == '#' || ch == ';') && !quoted:
+			return strings.TrimSpace(builder.String())
+		default:
+			builder.WriteByte(ch)
+		}
+	}
+	return strings.TrimSpace(builder.String())
+}
+
+/** Parses a boolean of git config */
+func parseGitConfigBool(value string, variable string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "true", "yes", "on", "1":
+		return true, nil
+	case "false", "no", "off", "0", "":
+		return false, nil
+	default:
+		return false, errors.New("bad boolean config value '" + value + "' for '" + variable + "'")
+	}
+}
+
+/**
+ * Probes whether the file system of the directory compares the names case-insensitively. A temporary file is created
+ * in the directory and looked up with a different case. `git init` sets `core.ignoreCase` this way.
+ *
+ * @param {string} directory A writable directory
+ * @returns {(bool, error)} Whether the names are case-insensitive, or the error of creating the temporary file
+ */
+func ProbeIgnoreCase(directory string) (bool, error) {
+	file, err := os.CreateTemp(directory, ".globify-gitignore-case-")
+	if err != nil {
+		return false, err
+	}
+	name := file.Name()
+	defer os.Remove(name)
+	if err := file.Close(); err != nil {
+		return false, err
+	}
+	info, err := os.Lstat(name)
+	if err != nil {
+		return false, err
+	}
+	base := filepath.Base(name)
+	otherInfo, err := os.Lstat(filepath.Join(filepath.Dir(name), strings.ToUpper(base)))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	return os.SameFile(info, otherInfo), nil
+}
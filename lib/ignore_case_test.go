@@ -0,0 +1,107 @@
+package lib
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGitConfigValue(t *testing.T) {
+	content := `# a comment
+[core]
+	repositoryformatversion = 0
+	ignorecase = false
+[remote "origin"]
+	ignorecase = true
+[Core] ignoreCase = "tr"ue ; a comment
+[user]
+	ignorecase = false
+`
+	value, ok := gitConfigValue(content, "core", "ignorecase")
+	assert.True(t, ok)
+	assert.Equal(t, value, "true")
+
+	// a variable without a value is true
+	value, ok = gitConfigValue("[core]\n\tignoreCase\n", "core", "ignorecase")
+	assert.True(t, ok)
+	assert.Equal(t, value, "true")
+
+	_, ok = gitConfigValue("[core]\n\tbare = false\n", "core", "ignorecase")
+	assert.False(t, ok)
+}
+
+/** Writes the files of a map (the paths are relative to the directory) */
+func writeFiles(t *testing.T, directory string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(directory, filepath.FromSlash(name))
+		assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.Nil(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+}
+
+func TestReadGitIgnoreCase(t *testing.T) {
+	directory := t.TempDir()
+	writeFiles(t, directory, map[string]string{
+		"repository/.git/config":                "[core]\n\tignorecase = true\n",
+		"repository/.git/worktrees/w/commondir": "../..\n",
+		"worktree/.git":                         "gitdir: ../repository/.git/worktrees/w\n",
+		"unset/.git/config":                     "[core]\n\tbare = false\n",
+		"bad/.git/config":                       "[core]\n\tignorecase = maybe\n",
+	})
+
+	ignoreCase, ok, err := ReadGitIgnoreCase(filepath.Join(directory, "repository", ".git"))
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.True(t, ignoreCase)
+
+	// a worktree uses the config of the repository
+	ignoreCase, ok, err = ReadGitIgnoreCase(filepath.Join(directory, "worktree", ".git"))
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.True(t, ignoreCase)
+
+	_, ok, err = ReadGitIgnoreCase(filepath.Join(directory, "unset", ".git"))
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	_, ok, err = ReadGitIgnoreCase(filepath.Join(directory, "missing", ".git"))
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	_, _, err = ReadGitIgnoreCase(filepath.Join(directory, "bad", ".git"))
+	assert.EqualError(t, err, "bad boolean config value 'maybe' for 'core.ignorecase'")
+}
+
+func TestDetectIgnoreCase(t *testing.T) {
+	directory := t.TempDir()
+	writeFiles(t, directory, map[string]string{
+		".git/config": "[core]\n\tignorecase = true\n",
+	})
+	ignoreCase, err := DetectIgnoreCase(directory)
+	assert.Nil(t, err)
+	assert.True(t, ignoreCase)
+
+	// like git, it is false without the config, and the file system is not probed
+	directory = t.TempDir()
+	ignoreCase, err = DetectIgnoreCase(directory)
+	assert.Nil(t, err)
+	assert.False(t, ignoreCase)
+	entries, err := os.ReadDir(directory)
+	assert.Nil(t, err)
+	assert.Equal(t, len(entries), 0)
+}
+
+func TestProbeIgnoreCase(t *testing.T) {
+	directory := t.TempDir()
+	_, err := ProbeIgnoreCase(directory)
+	assert.Nil(t, err)
+	// the temporary file is removed
+	entries, err := os.ReadDir(directory)
+	assert.Nil(t, err)
+	assert.Equal(t, len(entries), 0)
+
+	_, err = ProbeIgnoreCase(filepath.Join(directory, "missing"))
+	assert.NotNil(t, err)
+}
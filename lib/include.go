@@ -0,0 +1,236 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+/**
+ * The prefix of the lines that include the patterns of another ignore file (e.g. `#!include:.gitignore` in a
+ * `.gcloudignore`). Git reads these lines as comments.
+ */
+const IncludeDirective = "#!include:"
+
+/** An include directive that read an ignore file */
+type Include struct {
+	/** The ignore file of the directive */
+	Source string
+	/** The 1-based line number of the directive in `Source` */
+	Line int
+	/** The directive that read `Source`, or nil if it was not included */
+	IncludedBy *Include
+}
+
+/**
+ * Get the included path of an include directive
+ *
+ * @param {string} line One line of an ignore file
+ * @returns {(string, bool)} The path without the surrounding whitespace, and false if the line is not an include
+ *   directive
+ */
+func ParseIncludeDirective(line string) (string, bool) {
+	if !strings.HasPrefix(line, IncludeDirective) {
+		return "", false
+	}
+	return strings.TrimSpace(line[len(IncludeDirective):]), true
+}
+
+/**
+ * Reads an ignore file like `ParseGitIgnore`, and replaces its include directives (`#!include:file`) by the patterns of
+ * the included files. The included paths are relative to the directory of the file that includes them, and the
+ * included files can have include directives too. The included patterns apply like the ones of the including file, so
+ * they have its base directory. Their `Source` and `Line` are the ones in the included file, and `IncludedBy` is the
+ * directive that read it.
+ *
+ * @param {fs.FS} fsys The file system
+ * @param {string} name The path of the ignore file in fsys
+ * @param {Optional string} gitIgnoreDirectory The posix directory of the ignore file relative to the root of the
+ *   matching
+ * @returns {([]Pattern, error)} The patterns in the order of the files, or the error of reading the ignore file. The
+ *   error joins a `*ParseError` for each directive whose file cannot be read or that is a cycle (`ErrIncludeCycle`),
+ *   which is skipped.
+ */
+func ParseGitIgnoreWithIncludes(fsys fs.FS, name string, gitIgnoreDirectory ...string) ([]Pattern, error) {
+	content, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	parser := &includeParser{fsys: fsys}
+	if len(gitIgnoreDirectory) == 1 {
+		parser.base = cleanBase(gitIgnoreDirectory[0])
+	}
+	patterns := parser.parse(string(content), name, nil)
+	return patterns, errors.Join(parser.errs...)
+}
+
+/** Parses the ignore files and the files they include */
+type includeParser struct {
+	fsys fs.FS
+	base string
+	/** The files that are being read, from the outermost one */
+	reading []string
+	errs    []error
+}
+
+/**
+ * Parses the content of an ignore file
+ *
+ * @param {string} content The content of the ignore file
+ * @param {string} source The path of the ignore file in the file system. The included paths are relative to its
+ *   directory.
+ * @param {*Include} includedBy The directive that read the ignore file, or nil
+ * @returns {[]Pattern}
+ */
+func (parser *includeParser) parse(content string, source string, includedBy *Include) []Pattern {
+	parser.reading = append(parser.reading, source)
+	defer func() { parser.reading = parser.reading[:len(parser.reading)-1] }()
+
+	patterns := []Pattern{}
+	lines := strings.Split(strings.TrimPrefix(content, byteOrderMark), "\n")
+	for iLine := range lines {
+		line := trimCarriageReturn(lines[iLine])
+		if included, ok := ParseIncludeDirective(line); ok {
+			include := &Include{Source: source, Line: iLine + 1, IncludedBy: includedBy}
+			includedPatterns, err := parser.include(included, include)
+			if err != nil {
+				parser.errs = append(parser.errs, &ParseError{Source: source, Line: iLine + 1, Pattern: line, Err: err})
+			}
+			patterns = append(patterns, includedPatterns...)
+			continue
+		}
+		pattern, ok := ParseGitIgnorePattern(line)
+		if !ok {
+			continue
+		}
+		pattern.Base = parser.base
+		pattern.Source = source
+		pattern.Line = iLine + 1
+		pattern.IncludedBy = includedBy
+		patterns = append(patterns, pattern)
+	}
+	return patterns
+}
+
+/**
+ * Reads the patterns of an included file
+ *
+ * @param {string} included The path of the directive
+ * @param {*Include} include The directive
+ * @returns {([]Pattern, error)} The patterns, or the error of the directive
+ */
+func (parser *includeParser) include(included string, include *Include) ([]Pattern, error) {
+	if included == "" {
+		return nil, errors.New("missing path")
+	}
+	if strings.HasPrefix(included, "/") {
+		return nil, fmt.Errorf("the path %q is not relative", included)
+	}
+	name := path.Join(path.Dir(include.Source), included)
+	for iReading, reading := range parser.reading {
+		if reading == name {
+			return nil, fmt.Errorf("%w: %s", ErrIncludeCycle, strings.Join(append(parser.reading[iReading:], name), " -> "))
+		}
+	}
+	content, err := fs.ReadFile(parser.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	return parser.parse(string(content), name, include), nil
+}
+
+const gcloudIgnoreFileName = ".gcloudignore"
+
+/**
+ * The patterns that gcloud uses if a directory has no `.gcloudignore`. It also includes the `.gitignore` if the
+ * directory has one.
+ */
+const gcloudDefaultIgnore = `.gcloudignore
+.git
+.gitignore
+`
+
+/** The source of the default patterns of gcloud */
+const gcloudDefaultSource = "(gcloud defaults)"
+
+/**
+ * Reads the patterns of the `.gcloudignore` of a directory that is uploaded by gcloud (e.g. by `gcloud app deploy` or
+ * `gcloud functions deploy`). Like gcloud:
+ * - Only the `.gcloudignore` of the directory is read, and its include directives (e.g. `#!include:.gitignore`) are
+ *   resolved (see `ParseGitIgnoreWithIncludes`).
+ * - If there is no `.gcloudignore`, but there is a `.git` or a `.gitignore`, the default patterns ignore
+ *   `.gcloudignore`, `.git` and `.gitignore`, and the `.gitignore` is included. Their source is `(gcloud defaults)`.
+ *   Otherwise, nothing is ignored.
+ *
+ * @param {fs.FS} fsys The file system
+ * @param {string} root The uploaded directory. The sources of the patterns are relative to it.
+ * @returns {([]Pattern, error)} The patterns, and the errors of `ParseGitIgnoreWithIncludes`
+ */
+func ReadGCloudIgnore(fsys fs.FS, root string) ([]Pattern, error) {
+	rootFS, err := fs.Sub(fsys, root)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fs.Stat(rootFS, gcloudIgnoreFileName); !errors.Is(err, fs.ErrNotExist) {
+		return ParseGitIgnoreWithIncludes(rootFS, gcloudIgnoreFileName)
+	}
+
+	content := gcloudDefaultIgnore
+	_, gitErr := fs.Stat(rootFS, ".git")
+	_, gitIgnoreErr := fs.Stat(rootFS, gitIgnoreFileName)
+	if gitIgnoreErr == nil {
+		content += IncludeDirective + gitIgnoreFileName + "\n"
+	} else if gitErr != nil {
+		return []Pattern{}, nil
+	}
+	parser := &includeParser{fsys: rootFS}
+	patterns := parser.parse(content, gcloudDefaultSource, nil)
+	return patterns, errors.Join(parser.errs...)
+}
+
+/**
+ * Lists the files that gcloud would upload from a directory, without running gcloud. The paths ignored by the patterns
+ * of `ReadGCloudIgnore` are skipped, and the ignored directories are not read.
+ *
+ * @param {fs.FS} fsys The file system
+ * @param {string} root The uploaded directory
+ * @returns {([]string, error)} The posix paths of the files relative to root in the order of `fs.WalkDir`, or the error
+ *   of reading the `.gcloudignore` or a directory. The files are also returned with the errors of the include
+ *   directives, which are skipped (see `ParseGitIgnoreWithIncludes`).
+ */
+func GCloudUploadFiles(fsys fs.FS, root string) ([]string, error) {
+	patterns, parseErr := ReadGCloudIgnore(fsys, root)
+	if patterns == nil {
+		return nil, parseErr
+	}
+	matcher := NewMatcher(patterns)
+	files := []string{}
+	err := fs.WalkDir(fsys, root, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if name == root {
+			return nil
+		}
+		relative := strings.TrimPrefix(name, root+"/")
+		if root == "." {
+			relative = name
+		}
+		if pattern := matcher.MatchingPattern(relative, entry.IsDir()); pattern != nil && !pattern.Negated {
+			if entry.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !entry.IsDir() {
+			files = append(files, relative)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, parseErr
+}
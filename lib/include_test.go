@@ -0,0 +1,169 @@
+package lib
+
+import (
+	"errors"
+	"io/fs"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseIncludeDirective(t *testing.T) {
+	included, ok := ParseIncludeDirective("#!include:.gitignore")
+	assert.True(t, ok)
+	assert.Equal(t, included, ".gitignore")
+	included, ok = ParseIncludeDirective("#!include: sub/ignore \r")
+	assert.True(t, ok)
+	assert.Equal(t, included, "sub/ignore")
+	for _, line := range []string{"# include:.gitignore", " #!include:x", "#!include", "x"} {
+		_, ok = ParseIncludeDirective(line)
+		assert.False(t, ok, line)
+	}
+	// git reads them as comments
+	assert.True(t, IsGitIgnoreComment("#!include:.gitignore"))
+}
+
+func TestParseGitIgnoreWithIncludes(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app/.gcloudignore":    {Data: []byte("*.log\n#!include:.gitignore\n!keep.log\n#!include:../shared/ignore\n")},
+		"app/.gitignore":       {Data: []byte("# comment\nnode_modules/\n")},
+		"shared/ignore":        {Data: []byte("#!include:nested/ignore\n/dist\n")},
+		"shared/nested/ignore": {Data: []byte("*.tmp\n")},
+	}
+	patterns, err := ParseGitIgnoreWithIncludes(fsys, "app/.gcloudignore", "app")
+	assert.Nil(t, err)
+	assert.Equal(t, patternStrings(patterns), []string{"*.log", "node_modules/", "!keep.log", "*.tmp", "/dist"})
+
+	sources := []string{}
+	for _, pattern := range patterns {
+		assert.Equal(t, pattern.Base, "app")
+		sources = append(sources, pattern.Source+":"+includeChain(pattern.IncludedBy))
+	}
+	assert.Equal(t, sources, []string{
+		"app/.gcloudignore:",
+		"app/.gitignore:app/.gcloudignore:2",
+		"app/.gcloudignore:",
+		"shared/nested/ignore:shared/ignore:1 <- app/.gcloudignore:4",
+		"shared/ignore:app/.gcloudignore:4",
+	})
+	assert.Equal(t, patterns[1].Line, 2)
+	assert.Equal(t, patterns[3].Line, 1)
+
+	_, err = ParseGitIgnoreWithIncludes(fsys, "missing")
+	assert.True(t, errors.Is(err, fs.ErrNotExist))
+}
+
+/** The include directives of a pattern from the innermost one */
+func includeChain(include *Include) string {
+	chain := []string{}
+	for ; include != nil; include = include.IncludedBy {
+		chain = append(chain, include.Source+":"+strconv.Itoa(include.Line))
+	}
+	return strings.Join(chain, " <- ")
+}
+
+func TestParseGitIgnoreWithIncludesErrors(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a":     {Data: []byte("x\n#!include:b\n#!include:missing\n#!include:\n#!include:/abs\n#!include:a\n")},
+		"b":     {Data: []byte("y\n#!include:sub/c\n")},
+		"sub/c": {Data: []byte("z\n#!include:../a\n")},
+	}
+	patterns, err := ParseGitIgnoreWithIncludes(fsys, "a")
+	assert.Equal(t, patternStrings(patterns), []string{"x", "y", "z"})
+	assert.True(t, errors.Is(err, ErrIncludeCycle))
+	assert.True(t, errors.Is(err, fs.ErrNotExist))
+	lines := strings.Split(err.Error(), "\n")
+	assert.Equal(t, lines, []string{
+		`sub/c: line 2: "#!include:../a": include cycle: a -> b -> sub/c -> a`,
+		`a: line 3: "#!include:missing": open missing: file does not exist`,
+		`a: line 4: "#!include:": missing path`,
+		`a: line 5: "#!include:/abs": the path "/abs" is not relative`,
+		`a: line 6: "#!include:a": include cycle: a -> a`,
+	})
+
+	var parseError *ParseError
+	assert.True(t, errors.As(err, &parseError))
+	assert.Equal(t, parseError.Source, "sub/c")
+}
+
+func TestReadGCloudIgnore(t *testing.T) {
+	cases := []struct {
+		name     string
+		files    fstest.MapFS
+		expected []string
+	}{
+		{
+			"the .gcloudignore",
+			fstest.MapFS{"app/.gcloudignore": {Data: []byte("*.md\n#!include:.gitignore\n")}, "app/.gitignore": {Data: []byte("/dist\n")}},
+			[]string{"*.md", "/dist"},
+		},
+		{
+			"the defaults include the .gitignore",
+			fstest.MapFS{"app/.gitignore": {Data: []byte("/dist\n")}},
+			[]string{".gcloudignore", ".git", ".gitignore", "/dist"},
+		},
+		{
+			"the defaults without a .gitignore",
+			fstest.MapFS{"app/.git/HEAD": {}},
+			[]string{".gcloudignore", ".git", ".gitignore"},
+		},
+		{
+			"nothing is ignored without git",
+			fstest.MapFS{"app/main.go": {}},
+			[]string{},
+		},
+	}
+	for _, testCase := range cases {
+		patterns, err := ReadGCloudIgnore(testCase.files, "app")
+		assert.Nil(t, err, testCase.name)
+		assert.Equal(t, patternStrings(patterns), testCase.expected, testCase.name)
+	}
+
+	patterns, err := ReadGCloudIgnore(fstest.MapFS{"app/.gitignore": {Data: []byte("/dist\n")}}, "app")
+	assert.Nil(t, err)
+	assert.Equal(t, patterns[0].Source, gcloudDefaultSource)
+	assert.Equal(t, patterns[3].Source, ".gitignore")
+	assert.Equal(t, *patterns[3].IncludedBy, Include{Source: gcloudDefaultSource, Line: 4})
+
+	_, err = ReadGCloudIgnore(fstest.MapFS{"app/.gcloudignore": {Data: []byte("#!include:.gitignore\n")}}, "app")
+	assert.True(t, errors.Is(err, fs.ErrNotExist))
+}
+
+func TestGCloudUploadFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app/.gcloudignore":      {Data: []byte("#!include:.gitignore\n.gcloudignore\n*.md\n!README.md\n")},
+		"app/.gitignore":         {Data: []byte("node_modules/\n/dist\n")},
+		"app/README.md":          {},
+		"app/CHANGELOG.md":       {},
+		"app/main.go":            {},
+		"app/dist/a.js":          {},
+		"app/node_modules/x/a":   {},
+		"app/pkg/node_modules/y": {},
+		"app/pkg/dist/b.js":      {},
+		"app/pkg/.gitignore":     {Data: []byte("*\n")},
+	}
+	files, err := GCloudUploadFiles(fsys, "app")
+	assert.Nil(t, err)
+	// only the root .gitignore is included
+	assert.Equal(t, files, []string{".gitignore", "README.md", "main.go", "pkg/.gitignore", "pkg/dist/b.js"})
+
+	files, err = GCloudUploadFiles(fstest.MapFS{"main.go": {}, ".git/HEAD": {}}, ".")
+	assert.Nil(t, err)
+	assert.Equal(t, files, []string{"main.go"})
+
+	// the directives that cannot be read are skipped
+	files, err = GCloudUploadFiles(fstest.MapFS{
+		".gcloudignore": {Data: []byte("#!include:missing\n*.md\n")},
+		"README.md":     {},
+		"main.go":       {},
+	}, ".")
+	assert.True(t, errors.Is(err, fs.ErrNotExist))
+	assert.Equal(t, err.Error(), `.gcloudignore: line 1: "#!include:missing": open missing: file does not exist`)
+	assert.Equal(t, files, []string{".gcloudignore", "main.go"})
+
+	_, err = GCloudUploadFiles(fstest.MapFS{".gcloudignore": {Mode: fs.ModeDir}}, ".")
+	assert.NotNil(t, err)
+}
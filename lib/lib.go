@@ -1,22 +1,26 @@
 package lib
 
 import (
+	"errors"
+	"fmt"
 	"io/fs"
 	"os"
 	"path"
-	"regexp"
 	"strings"
-
-	"github.com/lithammer/dedent"
+	"syscall"
 )
 
 /**
- * Converts given path to Posix (replacing \ with /)
+ * Converts given path to Posix (replacing \ with /). The extended prefix of a path with a drive or a share is removed
+ * (e.g. `\\?\C:\dir` becomes `C:/dir`).
  *
  * @param {string} givenPath Path to convert
  * @returns {string} Converted filepath
  */
 func PosixifyPath(givenPath string) string {
+	if windowsPath := ParseWindowsPath(givenPath); windowsPath.Kind == WindowsPathExtended {
+		return windowsPath.PosixVolume() + strings.ReplaceAll(windowsPath.Path, "\\", "/")
+	}
 	return strings.ReplaceAll(givenPath, "\\", "/")
 }
 
@@ -30,6 +34,11 @@ func RemoveEndingSlash(givenPath string) string {
 	return strings.TrimRight(givenPath, "/")
 }
 
+/** The posix directory with one trailing slash, to prefix the globs with (e.g. `C:/` for `C:\\`) */
+func globDirectoryPrefix(givenDirectory string) string {
+	return RemoveEndingSlash(PosixifyPath(givenDirectory)) + "/"
+}
+
 /**
  * Globifies a directory
  *
@@ -39,9 +48,18 @@ func GlobifyDirectory(givenDirectory string) string {
 	return RemoveEndingSlash(PosixifyPath(givenDirectory)) + "/**"
 }
 
+/** The whitespace of the patterns (`\s` of regular expressions) */
+func isWhiteSpace(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\f' || ch == '\r'
+}
+
 func IsEmptyLine(str string) bool {
-	whiteSpaceRegex := regexp.MustCompile(`^\s*$`)
-	return whiteSpaceRegex.MatchString(str)
+	for i := 0; i < len(str); i++ {
+		if !isWhiteSpace(str[i]) {
+			return false
+		}
+	}
+	return true
 }
 
 /**
@@ -49,27 +67,35 @@ func IsEmptyLine(str string) bool {
  * with a hash.
  */
 func IsGitIgnoreComment(pattern string) bool {
-	return pattern[0] == '#'
+	return strings.HasPrefix(pattern, "#")
 }
 
 /** Trailing spaces should be removed unless they are quoted with backslash ("\ "). */
 func TrimTrailingWhitespace(str string) string {
-	escaped_trailing_whitespace := regexp.MustCompile(`\\\s+$`)
-	if !escaped_trailing_whitespace.MatchString(str) {
-		trailing_whitespace := regexp.MustCompile(`\s+$`)
-		// No escaped trailing whitespace, remove
-		return trailing_whitespace.ReplaceAllString(str, "")
-	} else {
+	end := len(str)
+	for end > 0 && isWhiteSpace(str[end-1]) {
+		end--
+	}
+	if end != len(str) && end > 0 && str[end-1] == '\\' {
 		// Trailing whitespace detected, remove only the backslash
-		backslash := regexp.MustCompile(`\\(\s+)$`)
-		return backslash.ReplaceAllString(str, "$1")
+		return str[:end-1] + str[end:]
 	}
+	// No escaped trailing whitespace, remove
+	return str[:end]
 }
 
 /** Remove leading whitespace */
 func TrimLeadingWhiteSpace(str string) string {
-	leading_whitespace := regexp.MustCompile(`^\s+`)
-	return leading_whitespace.ReplaceAllString(str, "")
+	return str[leadingWhiteSpace(str):]
+}
+
+/** The length of the leading whitespace */
+func leadingWhiteSpace(str string) int {
+	start := 0
+	for start < len(str) && isWhiteSpace(str[start]) {
+		start++
+	}
+	return start
 }
 
 /** Remove whitespace from a gitignore entry */
@@ -77,41 +103,146 @@ func TrimWhiteSpace(str string) string {
 	return TrimLeadingWhiteSpace(TrimTrailingWhitespace(str))
 }
 
-/** Enum that specifies the path type. 0 for file, 1 for directory, 2 for others */
+/**
+ * Enum that specifies the path type. 0 for file, 1 for directory, 2 for others (e.g. a missing path), and the kinds of
+ * the special files after them
+ */
 type PathType uint
 
 const (
 	PathTypeFile      PathType = 0
 	PathTypeDirectory PathType = 1
 	PathTypeOther     PathType = 2
+	/** A symbolic link that is not followed. Like git, it is converted like a file. */
+	PathTypeSymlink   PathType = 3
+	PathTypeNamedPipe PathType = 4
+	PathTypeSocket    PathType = 5
+	/** A character or a block device */
+	PathTypeDevice PathType = 6
 )
 
+/** The names of the path types */
+var pathTypeNames = []string{
+	PathTypeFile:      "file",
+	PathTypeDirectory: "directory",
+	PathTypeOther:     "other",
+	PathTypeSymlink:   "symlink",
+	PathTypeNamedPipe: "named-pipe",
+	PathTypeSocket:    "socket",
+	PathTypeDevice:    "device",
+}
+
+/** The name of the path type */
+func (pathType PathType) String() string {
+	if int(pathType) < len(pathTypeNames) {
+		return pathTypeNames[pathType]
+	}
+	return fmt.Sprintf("PathType(%d)", uint(pathType))
+}
+
 /**
- * Get the type of the given path
+ * Get the path type with the given name
  *
- * @param {string} givenPath Absolute path
- * @returns {PathType}
+ * @param {string} name The name of the path type (e.g. `file` or `named-pipe`)
+ * @returns {(PathType, error)} The path type or an error if the name is unknown
  */
-func GetPathType(filepath string) PathType {
-	pathStat, err := os.Lstat(filepath)
+func ParsePathType(name string) (PathType, error) {
+	for iPathType, pathTypeName := range pathTypeNames {
+		if pathTypeName == name {
+			return PathType(iPathType), nil
+		}
+	}
+	return PathTypeOther, fmt.Errorf("unknown path type %q (expected one of %s)", name, strings.Join(pathTypeNames, ", "))
+}
+
+/** Encodes the path type as its name (e.g. in JSON) */
+func (pathType PathType) MarshalText() ([]byte, error) {
+	if int(pathType) >= len(pathTypeNames) {
+		return nil, fmt.Errorf("unknown path type %d", uint(pathType))
+	}
+	return []byte(pathTypeNames[pathType]), nil
+}
+
+/** Decodes the name of a path type (e.g. in JSON) */
+func (pathType *PathType) UnmarshalText(text []byte) error {
+	parsed, err := ParsePathType(string(text))
 	if err != nil {
-		return PathTypeOther
+		return err
 	}
-	switch mode := pathStat.Mode(); {
+	*pathType = parsed
+	return nil
+}
+
+/**
+ * Get the path type of a file mode
+ *
+ * @param {fs.FileMode} mode The mode (e.g. of `os.Lstat` or `fs.DirEntry.Type`)
+ * @returns {PathType}
+ */
+func PathTypeOfMode(mode fs.FileMode) PathType {
+	switch {
 	case mode.IsRegular():
 		return PathTypeFile
 	case mode.IsDir():
 		return PathTypeDirectory
 	case mode&fs.ModeSymlink != 0:
-		return PathTypeOther
+		return PathTypeSymlink
 	case mode&fs.ModeNamedPipe != 0:
-		return PathTypeOther
+		return PathTypeNamedPipe
+	case mode&fs.ModeSocket != 0:
+		return PathTypeSocket
+	case mode&fs.ModeDevice != 0:
+		return PathTypeDevice
 	default:
 		return PathTypeOther
 	}
 }
 
-/// Is this string an invalid path?
+/**
+ * Get the type of the given path
+ *
+ * @param {string} givenPath Absolute path
+ * @param {Optional bool} followSymlinks Get the type of the target of a symbolic link (see `ProbePathType`)
+ * @returns {PathType} The type, or `PathTypeOther` if the path cannot be probed (see `ProbePathType`)
+ */
+func GetPathType(filepath string, followSymlinks ...bool) PathType {
+	pathType, _ := ProbePathType(filepath, followSymlinks...)
+	return pathType
+}
+
+/**
+ * Get the type of the given path, and report the errors of probing it
+ *
+ * @param {string} givenPath Absolute path
+ * @param {Optional bool} followSymlinks Get the type of the target of a symbolic link. By default, a symbolic link is
+ *   `PathTypeSymlink` like for git, which never follows them. A broken link is `PathTypeSymlink` in both cases.
+ * @returns {(PathType, error)} The type. A missing path is `PathTypeOther`. The other failures (e.g. a permission
+ *   error) return `PathTypeOther` and a `*PathProbeError`.
+ */
+func ProbePathType(givenPath string, followSymlinks ...bool) (PathType, error) {
+	pathStat, err := os.Lstat(givenPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) || errors.Is(err, syscall.ENOTDIR) {
+			return PathTypeOther, nil
+		}
+		return PathTypeOther, &PathProbeError{Path: givenPath, Err: err}
+	}
+	pathType := PathTypeOfMode(pathStat.Mode())
+	if pathType == PathTypeSymlink && len(followSymlinks) == 1 && followSymlinks[0] {
+		targetStat, err := os.Stat(givenPath)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) || errors.Is(err, syscall.ENOTDIR) {
+				// a broken link
+				return PathTypeSymlink, nil
+			}
+			return PathTypeOther, &PathProbeError{Path: givenPath, Err: err}
+		}
+		pathType = PathTypeOfMode(targetStat.Mode())
+	}
+	return pathType, nil
+}
+
+/** Is this string an invalid path? */
 func IsInvalidPath(path string, extended bool) bool {
 	/*
 	 * Go port of
@@ -125,9 +256,11 @@ func IsInvalidPath(path string, extended bool) bool {
 		return true
 	}
 
+	windowsPath := ParseWindowsPath(path)
+
 	// https://msdn.microsoft.com/en-us/library/windows/desktop/aa365247(v=vs.85).aspx#maxpath
 	MAX_PATH := 260
-	if extended {
+	if extended || windowsPath.Kind == WindowsPathExtended {
 		MAX_PATH = 32767
 	}
 
@@ -135,31 +268,41 @@ func IsInvalidPath(path string, extended bool) bool {
 		return true
 	}
 
-	// TODO
-	// const rootPath = path.parse(path).root
-	// if rootPath {
-	// 	path = path.slice(rootPath.length)
-	// }
+	// the colon of the drive and the `?` of the extended prefix are allowed
+	path = windowsPath.Path
 
 	// https://msdn.microsoft.com/en-us/library/windows/desktop/aa365247(v=vs.85).aspx#Naming_Conventions
-	invalidFileRegex := regexp.MustCompile(`[<>:"|?*]`)
-	return invalidFileRegex.MatchString(path)
+	if strings.ContainsAny(path, `<>:"|?*`) {
+		return true
+	}
+	if windowsPath.Kind == WindowsPathExtended || windowsPath.Kind == WindowsPathDevice {
+		// the names of these paths are not interpreted
+		return false
+	}
+	for path != "" {
+		end := componentEnd(path)
+		if IsReservedWindowsName(path[:end]) {
+			return true
+		}
+		path = strings.TrimLeft(path[end:], `\/`)
+	}
+	return false
 }
 
-/// Is this string a valid path
+/** Is this string a valid path */
 func IsPath(path string, extended bool) bool {
 	return !IsInvalidPath(path, extended)
 }
 
-/// Unique array
+/** Unique array. The duplicates are removed in place. */
 func unique(arr []string) []string {
-	occurred := map[string]bool{}
-	result := []string{}
+	occurred := make(map[string]struct{}, len(arr))
+	result := arr[:0]
 	for elm := range arr {
 		// check if already the mapped
-		// variable is set to true or not
-		if !occurred[arr[elm]] {
-			occurred[arr[elm]] = true
+		// variable is set or not
+		if _, ok := occurred[arr[elm]]; !ok {
+			occurred[arr[elm]] = struct{}{}
 
 			// Append to result slice.
 			result = append(result, arr[elm])
@@ -172,7 +315,7 @@ func unique(arr []string) []string {
 /**
  * @param {string} gitIgnoreEntry One git ignore entry
  * @param {Optional string} gitIgnoreDirectory The directory of gitignore
- * @returns {[string] | [string, string]} The equivalent glob
+ * @returns {[string] | [string, string]} The equivalent glob. It is empty if the entry is empty (e.g. `!` or `/`).
  *
  * NOTE: it expects a **valid** non-comment git-ignore entry  with no surrounding whitespace.
  * NOTE: Gitignore expects that paths are posixified. So, if you are passing Windows path to this function directly without poxifying them (using {PosixifyPath}), you are passing invalid gitignore entry, and so you will get invalid Glob pattern.
@@ -181,38 +324,179 @@ func GlobifyGitIgnoreEntry(
 	gitIgnoreEntry string,
 	gitIgnoreDirectory ...string,
 ) []string {
+	options := GlobifyOptions{}
+	if len(gitIgnoreDirectory) == 1 { // TODO find a better way for optional arguments in Go
+		options.Directory = gitIgnoreDirectory[0]
+	}
+	prefix, _ := options.basePrefix()
+	globs, _ := globifyGitIgnoreEntry(gitIgnoreEntry, options, prefix)
+	return globs
+}
+
+/**
+ * Like `GlobifyGitIgnoreEntry`, but checks the entry and reports the errors instead of converting a malformed entry
+ *
+ * @param {string} gitIgnoreEntry One git ignore entry with no surrounding whitespace
+ * @param {GlobifyOptions} options The options of the conversion
+ * @returns {([]string, error)} The equivalent globs, or a `*ParseError` if the entry is malformed (e.g. it wraps
+ *   `ErrEmptyPattern`), or a `*PathProbeError` if the type of an anchored entry cannot be probed
+ */
+func GlobifyGitIgnoreEntryWithOptions(
+	gitIgnoreEntry string,
+	options GlobifyOptions,
+) ([]string, error) {
+	if err := checkEntry(gitIgnoreEntry); err != nil {
+		return nil, err
+	}
+	prefix, err := options.basePrefix()
+	if err != nil {
+		return nil, err
+	}
+	return globifyGitIgnoreEntry(gitIgnoreEntry, options, prefix)
+}
+
+/** Checks an entry with no surrounding whitespace. The column of the returned `*ParseError` is relative to the entry. */
+func checkEntry(gitIgnoreEntry string) *ParseError {
+	prefix := 0
+	if strings.HasPrefix(gitIgnoreEntry, "!") {
+		prefix = 1
+	}
+	offset, err := checkPattern(strings.TrimSuffix(gitIgnoreEntry[prefix:], "/"))
+	if err != nil {
+		return &ParseError{Column: prefix + offset + 1, Pattern: gitIgnoreEntry, Err: err}
+	}
+	return nil
+}
+
+/**
+ * Converts an entry. An empty entry has no globs. If the type of an anchored entry cannot be probed, it is converted
+ * like a missing path, and the `*PathProbeError` is returned with the globs. The prefix is the escaped base directory
+ * (see `GlobifyOptions.basePrefix`). The globs are canonical (see `CanonicalGlob`).
+ */
+func globifyGitIgnoreEntry(
+	gitIgnoreEntry string,
+	options GlobifyOptions,
+	prefix string,
+) ([]string, error) {
+	globs, err := globifyEntryGlobs(gitIgnoreEntry, options, prefix)
+	return globs.slice(), err
+}
+
+/** The globs of an entry */
+type entryGlobs struct {
+	/** The glob of the paths that the entry matches, or empty if it only matches the directories */
+	path string
+	/** The glob of the content of the directories that the entry matches, or empty if it does not descend into them */
+	content string
+	/** The entry is negated, so the globs re-include the paths */
+	negated bool
+}
+
+/** The non-empty globs of an entry */
+func (globs entryGlobs) slice() []string {
+	result := []string{}
+	for _, glob := range []string{globs.path, globs.content} {
+		if glob != "" {
+			result = append(result, glob)
+		}
+	}
+	return result
+}
+
+/** Like `globifyGitIgnoreEntry`, but keeps the glob of the paths apart from the glob of the content */
+func globifyEntryGlobs(
+	gitIgnoreEntry string,
+	options GlobifyOptions,
+	prefix string,
+) (entryGlobs, error) {
+	globs, err := convertGitIgnoreEntry(gitIgnoreEntry, options, prefix)
+	globs.path = CanonicalGlob(globs.path)
+	globs.content = CanonicalGlob(globs.content)
+	return globs, err
+}
+
+/** Converts an entry to the globs before they are canonicalized */
+func convertGitIgnoreEntry(
+	gitIgnoreEntry string,
+	options GlobifyOptions,
+	prefix string,
+) (entryGlobs, error) {
 	// output glob entry
-	entry := gitIgnoreEntry
+	entry := options.Normalization.Normalize(gitIgnoreEntry)
 	// Process the entry beginning
 	// '!' in .gitignore means to force include the pattern
 	// remove "!" to allow the processing of the pattern and swap ! in the end of the loop
 	forceInclude := false
 
-	hasGitIgnoreDirectory := len(gitIgnoreDirectory) == 1 // TODO find a better way for optional arguments in Go
+	hasGitIgnoreDirectory := options.Directory != ""
 
-	if entry[0] == '!' {
+	if strings.HasPrefix(entry, "!") {
 		entry = entry[1:]
 		forceInclude = true
 	}
+	if entry == "" {
+		// an empty pattern matches nothing
+		return entryGlobs{}, nil
+	}
+
+	// an absolute path on a drive (e.g. `C:/dir`) is not relative to the directory of the gitignore. An entry that starts
+	// with `//` is anchored for git (and matches nothing), so it is not read as a network share.
+	volume := ""
+	if windowsPath := ParseWindowsPath(entry); windowsPath.Kind == WindowsPathDrive && windowsPath.IsAbs() {
+		volume = options.Dialect.Escape(windowsPath.PosixVolume() + "/")
+		entry = strings.TrimLeft(windowsPath.Path, "/")
+		if entry == "" {
+			// the whole volume
+			if forceInclude {
+				return entryGlobs{path: volume + "**", negated: true}, nil
+			}
+			return entryGlobs{path: "!" + volume + "**"}, nil
+		}
+	}
+
+	pathType := PathTypeOther
+	var probeErr error
+
+	// If there is a separator at the end of the pattern then it only matches directories
+	directoryOnly := false
+	if len(entry) > 1 && entry[len(entry)-1] == '/' {
+		entry = entry[:len(entry)-1]
+		pathType = PathTypeDirectory
+		directoryOnly = true
+		// a trailing `**` can match no directory in glob, so the directory needs its own component
+		if entry == "**" || strings.HasSuffix(entry, "/**") {
+			entry += "/*"
+		}
+	}
+
+	// With `IgnoreCase`, the globs also match the paths of the other cases, whose types can differ (e.g. a directory `A`
+	// and a file `a`), so an entry is converted like a missing path.
+	probe := !options.IgnoreCase
 
 	// If there is a separator at the beginning or middle (or both) of the pattern,
 	// then the pattern is relative to the directory level of the particular .gitignore file itself
 	// Process slash
 
-	pathType := PathTypeOther
-
-	if entry[0] == '/' {
+	if volume != "" {
+		if probe && pathType == PathTypeOther && IsPath(volume+entry, true) {
+			pathType, probeErr = options.probePathType(volume + entry)
+		}
+	} else if entry[0] == '/' {
 		// Patterns starting with '/' in gitignore are considered relative to the project directory while glob
 		// treats them as relative to the OS root directory.
 		// So we trim the slash to make it relative to project folder from glob perspective.
 		entry = entry[1:]
+		if entry == "" || entry[0] == '/' {
+			// git never matches an empty name, so e.g. `//server/share` matches nothing
+			return entryGlobs{}, nil
+		}
 
 		// Check if it is a directory or file
-		if IsPath(entry, true) {
+		if probe && pathType == PathTypeOther && IsPath(entry, true) {
 			if hasGitIgnoreDirectory {
-				pathType = GetPathType(path.Join(gitIgnoreDirectory[0], entry))
+				pathType, probeErr = options.probePathType(path.Join(options.Directory, entry))
 			} else {
-				pathType = GetPathType(entry)
+				pathType, probeErr = options.probePathType(entry)
 			}
 		}
 	} else {
@@ -223,26 +507,36 @@ func GlobifyGitIgnoreEntry(
 			if !strings.HasPrefix(entry, "**/") {
 				entry = "**/" + entry
 			}
-		} else if slashPlacement == len(entry)-1 {
-			// If there is a separator at the end of the pattern then it only matches directories
-			// slash is in the end
-			pathType = PathTypeDirectory
 		} else {
 			// has `/` in the middle so it is a relative path
 			// Check if it is a directory or file
-			if IsPath(entry, true) {
+			if probe && pathType == PathTypeOther && IsPath(entry, true) {
 				if hasGitIgnoreDirectory {
-					pathType = GetPathType(path.Join(gitIgnoreDirectory[0], entry))
+					pathType, probeErr = options.probePathType(path.Join(options.Directory, entry))
 				} else {
-					pathType = GetPathType(entry)
+					pathType, probeErr = options.probePathType(entry)
 				}
 			}
 		}
 	}
 
-	// prepend the absolute root directory
-	if hasGitIgnoreDirectory {
-		entry = PosixifyPath(gitIgnoreDirectory[0]) + "/" + entry
+	if options.IgnoreCase {
+		var ok bool
+		entry, ok = foldPatternCase(entry, !options.Dialect.HasNoCaseOption())
+		if !ok {
+			// the entry can never match
+			return entryGlobs{}, probeErr
+		}
+	}
+
+	// escape the characters that are special only in the glob syntax
+	entry = renderGlob(entry, options.Dialect)
+
+	// prepend the root directory
+	if volume != "" {
+		entry = volume + entry
+	} else {
+		entry = prefix + entry
 	}
 
 	// swap !
@@ -250,26 +544,30 @@ func GlobifyGitIgnoreEntry(
 		entry = "!" + entry
 	}
 
-	// TODO use a tagged union instead of an array?
-
 	// Process the entry ending
 	if pathType == PathTypeDirectory {
 		// in glob this is equal to `directory/**`
+		content := entry + "/**"
 		if strings.HasSuffix(entry, "/") {
-			return []string{entry + "**"}
-		} else {
-			return []string{entry + "/**"}
+			content = entry + "**"
+		}
+		if directoryOnly {
+			// the files of the same name are not matched
+			content = options.Dialect.contentGlob(strings.TrimSuffix(entry, "/"))
 		}
-	} else if pathType == PathTypeFile {
-		// return as is for file
-		return []string{entry}
+		return entryGlobs{content: content, negated: forceInclude}, probeErr
+	} else if pathType != PathTypeOther {
+		// return as is for file (and the other paths that cannot be descended into, e.g. a symbolic link)
+		return entryGlobs{path: entry, negated: forceInclude}, probeErr
 	} else if !strings.HasSuffix(entry, "/**") {
 		// the pattern can match both files and directories
 		// so we should include both `entry` and `entry/**`
-		content := entry + "/**"
-		return []string{entry, content}
+		return entryGlobs{path: entry, content: options.Dialect.contentGlob(entry), negated: forceInclude}, probeErr
 	} else {
-		return []string{entry}
+		// the pattern only matches the content of the directories, which it also descends into
+		content := options.Dialect.contentGlob(entry)
+		entry = options.Dialect.renderContent(CanonicalGlob(entry))
+		return entryGlobs{path: entry, content: content, negated: forceInclude}, probeErr
 	}
 }
 
@@ -284,43 +582,266 @@ func GlobifyGitIgnore(
 	gitIgnoreContent string,
 	gitIgnoreDirectory ...string,
 ) []string {
-	gitIgnoreContentDedented := dedent.Dedent(gitIgnoreContent)
-	gitIgnoreContentLines := strings.Split(gitIgnoreContentDedented, "\n")
-
-	gitIgnoreEntries := []string{}
-	for iLine := range gitIgnoreContentLines {
-		entry := gitIgnoreContentLines[iLine]
-		// Exclude empty lines and comments (filtering).
-		if !(IsEmptyLine(entry) || IsGitIgnoreComment(entry)) {
-			// Remove surrounding whitespace
-			entryTrimmed := TrimWhiteSpace(entry)
+	options := GlobifyOptions{}
+	if len(gitIgnoreDirectory) == 1 {
+		options.Directory = gitIgnoreDirectory[0]
+	}
+	// the malformed entries are skipped
+	globEntries, _ := GlobifyGitIgnoreWithOptions(gitIgnoreContent, options)
+	return globEntries
+}
+
+/** The options of the conversion of gitignore entries to globs */
+type GlobifyOptions struct {
+	/**
+	 * The directory of the gitignore. The globs are prefixed with it, and it is used to check if an entry is a file or a
+	 * directory. If empty, the globs are relative and the current working directory is checked.
+	 */
+	Directory string
+	/** The glob syntax of the output. Defaults to `DialectGlobby`. */
+	Dialect Dialect
+	/**
+	 * Match the ASCII letters case-insensitively like git with `core.ignoreCase` (see `DetectIgnoreCase`). If the
+	 * dialect has a case-insensitive option (see `Dialect.HasNoCaseOption`), it has to be set when matching the globs.
+	 * The types of the anchored entries are not probed, as the paths of the other cases can have other types.
+	 */
+	IgnoreCase bool
+	/**
+	 * How the directory is emitted at the start of the globs. Its glob syntax (e.g. `[` or `*`) is escaped in all the
+	 * modes. Defaults to `BaseAsIs`.
+	 */
+	Base BaseMode
+	/** The directory that the `BaseRelative` and `BaseDotRelative` globs are relative to. Defaults to the current working directory. */
+	RelativeTo string
+	/**
+	 * Probe the targets of the symbolic links, so an entry that is a link to a directory is converted like a directory.
+	 * Git never follows them, so by default a link is converted like a file.
+	 */
+	FollowSymlinks bool
+	/**
+	 * Convert the entries and the directory to a Unicode normalization form, so that the globs match the paths in that
+	 * form (e.g. `NormalizationNFD` for the names of macOS). Defaults to `NormalizationNone`.
+	 */
+	Normalization Normalization
+	/**
+	 * Parse the lines exactly like git: the leading whitespace is part of the pattern, only the unescaped trailing spaces
+	 * are removed, and the empty patterns (e.g. `!`) are skipped silently. By default, the common indentation of the
+	 * lines and their surrounding whitespace are removed.
+	 */
+	Strict bool
+}
+
+/** Probes the type of the path of an anchored entry */
+func (options GlobifyOptions) probePathType(givenPath string) (PathType, error) {
+	return ProbePathType(givenPath, options.FollowSymlinks)
+}
 
-			// out
-			gitIgnoreEntries = append(gitIgnoreEntries, entryTrimmed)
+/** Splits the first line (without the `\n`) from the rest of the content */
+func cutLine(content string) (string, string) {
+	if newLine := strings.IndexByte(content, '\n'); newLine != -1 {
+		return content[:newLine], content[newLine+1:]
+	}
+	return content, ""
+}
+
+/**
+ * The common leading spaces and tabs of the lines that are not blank. Like the `dedent` of Python, the lines that only
+ * have spaces and tabs are ignored.
+ */
+func commonMargin(content string) string {
+	margin := ""
+	hasMargin := false
+	for rest := content; rest != ""; {
+		var line string
+		line, rest = cutLine(rest)
+		line = trimCarriageReturn(line)
+		indent := 0
+		for indent < len(line) && (line[indent] == ' ' || line[indent] == '\t') {
+			indent++
+		}
+		if indent == len(line) {
+			continue
+		}
+		switch {
+		case !hasMargin:
+			margin, hasMargin = line[:indent], true
+		case strings.HasPrefix(line[:indent], margin):
+		case strings.HasPrefix(margin, line[:indent]):
+			margin = line[:indent]
+		default:
+			return ""
+		}
+	}
+	return margin
+}
+
+/**
+ * Removes the common indentation of the lines, and the spaces and tabs of the blank lines. This is convenient for the
+ * gitignores that are written as indented Go raw strings (e.g. in tests).
+ *
+ * NOTE: git treats the leading whitespace as a part of the patterns. So, do not use this on the real gitignore files.
+ *
+ * @param {string} text The text
+ * @returns {string} The dedented text
+ */
+func Dedent(text string) string {
+	margin := commonMargin(text)
+	var builder strings.Builder
+	builder.Grow(len(text))
+	for rest := text; rest != ""; {
+		var line string
+		line, rest = cutLine(rest)
+		if strings.Trim(line, " \t") != "" {
+			builder.WriteString(strings.TrimPrefix(line, margin))
+		}
+		if rest != "" || strings.HasSuffix(text, "\n") {
+			builder.WriteByte('\n')
 		}
 	}
-	gitIgnoreEntriesNum := len(gitIgnoreEntries)
+	return builder.String()
+}
+
+/**
+ * Globify the content of a `.gitignore` file with the given options
+ *
+ * @param {string} gitIgnoreContent The content of the gitignore file
+ * @param {GlobifyOptions} options The options of the conversion
+ * @returns {([]string, error)} An array of glob patterns, and an error that joins a `*ParseError` for each malformed
+ *   entry (which is skipped) and a `*PathProbeError` for each entry whose type could not be probed
+ */
+func GlobifyGitIgnoreWithOptions(
+	gitIgnoreContent string,
+	options GlobifyOptions,
+) ([]string, error) {
+	groups, err := globifyGitIgnoreGroups(gitIgnoreContent, options)
+	if groups == nil {
+		return nil, err
+	}
+	// remove duplicates in the end
+	return uniqueGlobs(groups.slice()), err
+}
+
+/**
+ * The globs of a gitignore file in their groups. The globs of several files (from the shallowest) are combined by
+ * appending the groups, so that every glob of the paths comes before the globs of the content.
+ */
+type globGroups struct {
+	/** The globs of the paths that the entries match */
+	paths []string
+	/** The globs of the content that the negated entries re-include */
+	reincluded []string
+	/** The globs of the content that the entries ignore */
+	content []string
+}
+
+/** Appends the groups of a deeper file */
+func (groups *globGroups) append(other *globGroups) {
+	groups.paths = append(groups.paths, other.paths...)
+	groups.reincluded = append(groups.reincluded, other.reincluded...)
+	groups.content = append(groups.content, other.content...)
+}
+
+/**
+ * The globs in order. The content comes last, and the re-included content comes before the ignored content, as git
+ * cannot re-include a path whose directory is excluded.
+ */
+func (groups *globGroups) slice() []string {
+	globs := make([]string, 0, len(groups.paths)+len(groups.reincluded)+len(groups.content))
+	return append(append(append(globs, groups.paths...), groups.reincluded...), groups.content...)
+}
+
+/** Like `GlobifyGitIgnoreWithOptions`, but keeps the groups of the globs apart and the duplicates */
+func globifyGitIgnoreGroups(gitIgnoreContent string, options GlobifyOptions) (*globGroups, error) {
+	gitIgnoreContent = strings.TrimPrefix(gitIgnoreContent, byteOrderMark)
+	// the common indentation of the lines is removed before looking for the comments
+	margin := ""
+	if !options.Strict {
+		margin = commonMargin(gitIgnoreContent)
+	}
 
-	globEntries := []string{} // TODO reserve at least gitIgnoreEntriesNum?
+	prefix, err := options.basePrefix()
+	if err != nil {
+		return nil, err
+	}
 
-	for iEntry := 0; iEntry < gitIgnoreEntriesNum; iEntry++ {
+	groups := &globGroups{}
+	errs := []error{}
+	for iLine, rest := 0, gitIgnoreContent; rest != ""; iLine++ {
+		var line string
+		line, rest = cutLine(rest)
+		line = trimCarriageReturn(line)
+		var entryTrimmed string
+		if options.Strict {
+			pattern, ok := ParseGitIgnorePattern(line)
+			if !ok {
+				continue
+			}
+			entryTrimmed = pattern.String()
+		} else {
+			entry := strings.TrimPrefix(line, margin)
+			// Exclude empty lines and comments (filtering).
+			if IsEmptyLine(entry) || IsGitIgnoreComment(entry) {
+				continue
+			}
+			// Remove surrounding whitespace
+			entryTrimmed = TrimWhiteSpace(entry)
+		}
 
-		globifyOutput := GlobifyGitIgnoreEntry(gitIgnoreEntries[iEntry], gitIgnoreDirectory...)
+		if err := checkEntry(entryTrimmed); err != nil {
+			err.Line = iLine + 1
+			// the columns are in the original line
+			if !options.Strict {
+				err.Column += leadingWhiteSpace(line)
+			}
+			errs = append(errs, err)
+			continue
+		}
 
-		// Check if `GlobifyGitIgnoreEntry` returns a pair or a string
-		if len(globifyOutput) == 1 {
-			// string
-			globEntries = append(globEntries, globifyOutput[0]) // Place the entry in the output array
+		globs, err := globifyEntryGlobs(entryTrimmed, options, prefix)
+		if err != nil {
+			// the entry is still converted like a missing path
+			errs = append(errs, err)
+		}
+
+		if globs.path != "" {
+			groups.paths = append(groups.paths, globs.path) // Place the entry in the output array
+		}
+		if globs.content == "" {
+			continue
+		}
+		// Push the content to the end
+		if globs.negated {
+			groups.reincluded = append(groups.reincluded, globs.content)
 		} else {
-			// pair
-			globEntries = append(globEntries,
-				globifyOutput[0], // Place the entry in the output array
-				globifyOutput[1]) // Push the additional entry
+			groups.content = append(groups.content, globs.content)
 		}
 	}
+	return groups, errors.Join(errs...)
+}
 
-	// remove duplicates in the end
-	return unique(globEntries)
+/**
+ * Removes the repeated globs, unless a glob of the other polarity came before the repetition (e.g. the last `!a` of `!a`,
+ * `a`, `!a`), as the last matching glob decides
+ */
+func uniqueGlobs(globs []string) []string {
+	/** The index of the last kept occurrence of a glob */
+	kept := make(map[string]int, len(globs))
+	lastNegated, lastIgnored := -1, -1
+	result := globs[:0]
+	for _, glob := range globs {
+		ignored := strings.HasPrefix(glob, "!")
+		if index, ok := kept[glob]; ok && ((ignored && lastNegated < index) || (!ignored && lastIgnored < index)) {
+			continue
+		}
+		kept[glob] = len(result)
+		if ignored {
+			lastIgnored = len(result)
+		} else {
+			lastNegated = len(result)
+		}
+		result = append(result, glob)
+	}
+	return result
 }
 
 /**
@@ -351,7 +872,7 @@ func GlobifyPath(
 	if len(givenDirectory) == 0 {
 		currentWorkingDirectory, err := os.Getwd()
 		if err == nil {
-			givenDirectory[0] = currentWorkingDirectory
+			givenDirectory = []string{currentWorkingDirectory}
 		}
 	}
 	return GlobifyGitIgnoreEntry(PosixifyPath(givenPath), givenDirectory...)
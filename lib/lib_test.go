@@ -1,7 +1,14 @@
 package lib
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
 	"log"
+	"os"
+	"path"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -10,6 +17,21 @@ import (
 func TestPosixifyPath(t *testing.T) {
 	assert.Equal(t, PosixifyPath("C:\\hey"), "C:/hey")
 	assert.Equal(t, PosixifyPath("hey/"), "hey/")
+	assert.Equal(t, PosixifyPath(`\\server\share\hey`), "//server/share/hey")
+	assert.Equal(t, PosixifyPath(`\\?\C:\hey`), "C:/hey")
+	assert.Equal(t, PosixifyPath(`\\?\UNC\server\share\hey`), "//server/share/hey")
+}
+
+func TestIsInvalidPath(t *testing.T) {
+	for _, path := range []string{"hey", "C:\\hey\\file.txt", "c:/hey", "C:hey", `\\server\share\hey`, `\\?\C:\hey`, `\\.\COM1`, "/hey/console"} {
+		assert.True(t, IsPath(path, false), path)
+	}
+	for _, path := range []string{"", "hey:there", "C:\\hey?", "a<b", "NUL", "dir/con.txt", "C:\\aux\\file", strings.Repeat("a", 260)} {
+		assert.True(t, IsInvalidPath(path, false), path)
+	}
+	// the extended paths are longer
+	assert.Equal(t, IsInvalidPath(`\\?\C:\`+strings.Repeat("a", 260), false), false)
+	assert.Equal(t, IsInvalidPath(strings.Repeat("a", 260), true), false)
 }
 
 func TestRemoveEndingSlash(t *testing.T) {
@@ -22,6 +44,8 @@ func TestIsEmptyLine(t *testing.T) {
 	assert.Equal(t, IsEmptyLine(" "), true)
 	assert.Equal(t, IsEmptyLine(" \n"), true)
 	assert.Equal(t, IsEmptyLine(" #"), false)
+	assert.Equal(t, IsEmptyLine(""), true)
+	assert.Equal(t, IsEmptyLine("\t\r\f"), true)
 }
 
 func TestTrimTrailingWhitespace(t *testing.T) {
@@ -29,6 +53,10 @@ func TestTrimTrailingWhitespace(t *testing.T) {
 	assert.Equal(t, TrimTrailingWhitespace("aa \\ "), "aa  ")
 	assert.Equal(t, TrimTrailingWhitespace("aa \\  "), "aa   ")
 	assert.Equal(t, TrimTrailingWhitespace("aa"), "aa")
+	assert.Equal(t, TrimTrailingWhitespace("aa\t\r"), "aa")
+	assert.Equal(t, TrimTrailingWhitespace("\\ "), " ")
+	assert.Equal(t, TrimTrailingWhitespace("a\\ b\\"), "a\\ b\\")
+	assert.Equal(t, TrimTrailingWhitespace(" "), "")
 }
 
 func TestTrimLeadingWhiteSpace(t *testing.T) {
@@ -37,6 +65,18 @@ func TestTrimLeadingWhiteSpace(t *testing.T) {
 	assert.Equal(t, TrimLeadingWhiteSpace(" \\ aa"), "\\ aa")
 }
 
+func TestCommonMargin(t *testing.T) {
+	assert.Equal(t, commonMargin("  a\n    b\n\n \n"), "  ")
+	assert.Equal(t, commonMargin("\ta\n\t\tb"), "\t")
+	assert.Equal(t, commonMargin("  a\n\tb"), "")
+	assert.Equal(t, commonMargin("a\n  b"), "")
+	assert.Equal(t, commonMargin("  \n"), "")
+
+	// the comments are found after removing the margin
+	globs := GlobifyGitIgnore("\n    # comment\n    *.log\n")
+	assert.Equal(t, globs, []string{`!**/*.log`, `!**/*.log/**`})
+}
+
 func TestTrimWhiteSpace(t *testing.T) {
 	assert.Equal(t, TrimWhiteSpace("aa  "), "aa")
 	assert.Equal(t, TrimWhiteSpace("aa \\ "), "aa  ")
@@ -56,6 +96,8 @@ func TestGlobifyDirectory(t *testing.T) {
 	assert.Equal(t, GlobifyDirectory("/home/"), "/home/**")
 	assert.Equal(t, GlobifyDirectory("C:\\hey\\"), "C:/hey/**")
 	assert.Equal(t, GlobifyDirectory("hey\\hey2\\"), "hey/hey2/**")
+	assert.Equal(t, GlobifyDirectory("C:\\"), "C:/**")
+	assert.Equal(t, GlobifyDirectory(`\\?\UNC\server\share\`), "//server/share/**")
 }
 
 func TestIsGitIgnoreComment(t *testing.T) {
@@ -64,6 +106,7 @@ func TestIsGitIgnoreComment(t *testing.T) {
 	assert.Equal(t, IsGitIgnoreComment(" #"), false)
 	assert.Equal(t, IsGitIgnoreComment(" "), false)
 	assert.Equal(t, IsGitIgnoreComment("aa"), false)
+	assert.Equal(t, IsGitIgnoreComment(""), false)
 }
 
 func TestGlobifyGitIgnoreEntry(t *testing.T) {
@@ -71,15 +114,32 @@ func TestGlobifyGitIgnoreEntry(t *testing.T) {
 	assert.Equal(t, GlobifyGitIgnoreEntry("dir_or_file"), []string{"!**/dir_or_file", "!**/dir_or_file/**"})
 
 	// Relative dir
-	assert.Equal(t, GlobifyGitIgnoreEntry("dir/"), []string{"!dir/**"})
+	assert.Equal(t, GlobifyGitIgnoreEntry("dir/"), []string{"!**/dir/**"})
 
 	// Absolute paths
 	assert.Equal(t, GlobifyGitIgnoreEntry("/abs_dir_or_file"), []string{"!abs_dir_or_file", "!abs_dir_or_file/**"})
 	assert.Equal(t, GlobifyGitIgnoreEntry("/abs_dir/abs_dir_or_file"), []string{"!abs_dir/abs_dir_or_file", "!abs_dir/abs_dir_or_file/**"})
-	assert.Equal(t, GlobifyGitIgnoreEntry("/abs_dir/abs_dir/"), []string{"!abs_dir/abs_dir/", "!abs_dir/abs_dir//**"})
+	assert.Equal(t, GlobifyGitIgnoreEntry("/abs_dir/abs_dir/"), []string{"!abs_dir/abs_dir/**"})
 	assert.Equal(t, GlobifyGitIgnoreEntry("C:/abs_dir_or_file"), []string{"!C:/abs_dir_or_file", "!C:/abs_dir_or_file/**"})
 	assert.Equal(t, GlobifyGitIgnoreEntry("C:/abs_dir/abs_dir_or_file"), []string{"!C:/abs_dir/abs_dir_or_file", "!C:/abs_dir/abs_dir_or_file/**"})
-	assert.Equal(t, GlobifyGitIgnoreEntry("C:/abs_dir/abs_dir/"), []string{"!C:/abs_dir/abs_dir/", "!C:/abs_dir/abs_dir//**"})
+	assert.Equal(t, GlobifyGitIgnoreEntry("C:/abs_dir/abs_dir/"), []string{"!C:/abs_dir/abs_dir/**"})
+
+	// the absolute Windows paths are not relative to the directory
+	assert.Equal(t, GlobifyGitIgnoreEntry("C:/abs_dir/", "root"), []string{"!C:/abs_dir/**"})
+	// only the drives are volumes, as git never matches the entries that start with `//`
+	assert.Equal(t, GlobifyGitIgnoreEntry("!//server/share/abs_dir_or_file", "root"), []string{})
+	assert.Equal(t, GlobifyGitIgnoreEntry("//?/C:/abs_dir/", "root"), []string{})
+	assert.Equal(t, GlobifyGitIgnoreEntry("//0!/0"), []string{})
+	assert.Equal(t, GlobifyGitIgnoreEntry("C:/", "root"), []string{"!C:/**"})
+	assert.Equal(t, GlobifyGitIgnoreEntry("C:dir_or_file", "root"), []string{"!root/**/C:dir_or_file", "!root/**/C:dir_or_file/**"})
+	assert.Equal(t, GlobifyGitIgnoreEntry("/abs_dir/", "C:\\"), []string{"!C:/abs_dir/**"})
+	assert.Equal(t, GlobifyGitIgnoreEntry("/abs_dir/", "\\\\server\\share\\"), []string{"!//server/share/abs_dir/**"})
+
+	// Empty patterns
+	assert.Equal(t, GlobifyGitIgnoreEntry(""), []string{})
+	assert.Equal(t, GlobifyGitIgnoreEntry("!"), []string{})
+	assert.Equal(t, GlobifyGitIgnoreEntry("/"), []string{})
+	assert.Equal(t, GlobifyGitIgnoreEntry("//"), []string{})
 }
 
 func TestGlobifyGitIgnore(t *testing.T) {
@@ -162,95 +222,97 @@ pnpm-debug.log
 *.tgz
 `
 	assert.Equal(t, GlobifyGitIgnore(gitignoreContent, "./fixtures"), []string{
-		`!./fixtures/**/.DS_Store`,
-		`!./fixtures/**/Thumbs.db`,
-		`!./fixtures/**/node_modules`,
-		`!./fixtures/**/package-lock.json`,
-		`!./fixtures/**/*.tsbuildinfo`,
-		`!./fixtures/**/dist`,
-		`!./fixtures/**/*.dll`,
-		`!./fixtures/**/*.exe`,
-		`!./fixtures/**/*.cmd`,
-		`!./fixtures/**/*.pdb`,
-		`!./fixtures/**/*.suo`,
-		`!./fixtures/**/*.js`,
-		`!./fixtures/**/*.user`,
-		`!./fixtures/**/*.cache`,
-		`!./fixtures/**/*.cs`,
-		`!./fixtures/**/*.sln`,
-		`!./fixtures/**/*.csproj`,
-		`!./fixtures/**/*.map`,
-		`!./fixtures/**/*.swp`,
-		`!./fixtures/**/*.code-workspace`,
-		`!./fixtures/**/*.log`,
-		`!./fixtures/**/_Resharper.DefinitelyTyped`,
-		`!./fixtures/**/bin`,
-		`!./fixtures/**/obj`,
-		`!./fixtures/**/Properties`,
-		`!./fixtures/**/*~`,
-		`!./fixtures/_infrastructure/tests/build`,
-		`!./fixtures/**/.idea`,
-		`!./fixtures/**/*.iml`,
-		`!./fixtures/**/*.js.map`,
-		`./fixtures/*.js/**`,
-		`./fixtures/scripts/new-package.js`,
-		`./fixtures/scripts/not-needed.js`,
-		`./fixtures/scripts/lint.js`,
-		`!./fixtures/**/npm-debug.log`,
-		`!./fixtures/**/.sublimets`,
-		`!./fixtures/.settings/launch.json`,
-		`!./fixtures/**/.vs`,
-		`!./fixtures/**/.vscode`,
-		`!./fixtures/**/.history`,
-		`!./fixtures/**/yarn.lock`,
-		`!./fixtures/**/shrinkwrap.yaml`,
-		`!./fixtures/**/pnpm-lock.yaml`,
-		`!./fixtures/**/pnpm-debug.log`,
-		`!./fixtures/**/*.tgz`,
-		`!./fixtures/**/.DS_Store/**`,
-		`!./fixtures/**/Thumbs.db/**`,
-		`!./fixtures/**/node_modules/**`,
-		`!./fixtures/**/package-lock.json/**`,
-		`!./fixtures/**/*.tsbuildinfo/**`,
-		`!./fixtures/**/dist/**`,
-		`!./fixtures/**/*.dll/**`,
-		`!./fixtures/**/*.exe/**`,
-		`!./fixtures/**/*.cmd/**`,
-		`!./fixtures/**/*.pdb/**`,
-		`!./fixtures/**/*.suo/**`,
-		`!./fixtures/**/*.js/**`,
-		`!./fixtures/**/*.user/**`,
-		`!./fixtures/**/*.cache/**`,
-		`!./fixtures/**/*.cs/**`,
-		`!./fixtures/**/*.sln/**`,
-		`!./fixtures/**/*.csproj/**`,
-		`!./fixtures/**/*.map/**`,
-		`!./fixtures/**/*.swp/**`,
-		`!./fixtures/**/*.code-workspace/**`,
-		`!./fixtures/**/*.log/**`,
-		`!./fixtures/**/_Resharper.DefinitelyTyped/**`,
-		`!./fixtures/**/bin/**`,
-		`!./fixtures/**/obj/**`,
-		`!./fixtures/**/Properties/**`,
-		`!./fixtures/**/*~/**`,
-		`!./fixtures/_infrastructure/tests/build/**`,
-		`!./fixtures/**/.idea/**`,
-		`!./fixtures/**/*.iml/**`,
-		`!./fixtures/**/*.js.map/**`,
-		`./fixtures/scripts/new-package.js/**`,
-		`./fixtures/scripts/not-needed.js/**`,
-		`./fixtures/scripts/lint.js/**`,
-		`!./fixtures/**/npm-debug.log/**`,
-		`!./fixtures/**/.sublimets/**`,
-		`!./fixtures/.settings/launch.json/**`,
-		`!./fixtures/**/.vs/**`,
-		`!./fixtures/**/.vscode/**`,
-		`!./fixtures/**/.history/**`,
-		`!./fixtures/**/yarn.lock/**`,
-		`!./fixtures/**/shrinkwrap.yaml/**`,
-		`!./fixtures/**/pnpm-lock.yaml/**`,
-		`!./fixtures/**/pnpm-debug.log/**`,
-		`!./fixtures/**/*.tgz/**`,
+		`!fixtures/**/.DS_Store`,
+		`!fixtures/**/Thumbs.db`,
+		`!fixtures/**/node_modules`,
+		`!fixtures/**/package-lock.json`,
+		`!fixtures/**/*.tsbuildinfo`,
+		`!fixtures/**/dist`,
+		`!fixtures/**/*.dll`,
+		`!fixtures/**/*.exe`,
+		`!fixtures/**/*.cmd`,
+		`!fixtures/**/*.pdb`,
+		`!fixtures/**/*.suo`,
+		`!fixtures/**/*.js`,
+		`!fixtures/**/*.user`,
+		`!fixtures/**/*.cache`,
+		`!fixtures/**/*.cs`,
+		`!fixtures/**/*.sln`,
+		`!fixtures/**/*.csproj`,
+		`!fixtures/**/*.map`,
+		`!fixtures/**/*.swp`,
+		`!fixtures/**/*.code-workspace`,
+		`!fixtures/**/*.log`,
+		`!fixtures/**/_Resharper.DefinitelyTyped`,
+		`!fixtures/**/bin`,
+		`!fixtures/**/obj`,
+		`!fixtures/**/Properties`,
+		`!fixtures/**/*~`,
+		`!fixtures/_infrastructure/tests/build`,
+		`!fixtures/**/.idea`,
+		`!fixtures/**/*.iml`,
+		`!fixtures/**/*.js.map`,
+		`fixtures/scripts/new-package.js`,
+		`fixtures/scripts/not-needed.js`,
+		`fixtures/scripts/lint.js`,
+		`!fixtures/**/node_modules`,
+		`!fixtures/**/package-lock.json`,
+		`!fixtures/**/npm-debug.log`,
+		`!fixtures/**/.sublimets`,
+		`!fixtures/.settings/launch.json`,
+		`!fixtures/**/.vs`,
+		`!fixtures/**/.vscode`,
+		`!fixtures/**/.history`,
+		`!fixtures/**/yarn.lock`,
+		`!fixtures/**/shrinkwrap.yaml`,
+		`!fixtures/**/pnpm-lock.yaml`,
+		`!fixtures/**/pnpm-debug.log`,
+		`!fixtures/**/*.tgz`,
+		`fixtures/**/*.js/**`,
+		`fixtures/scripts/new-package.js/**`,
+		`fixtures/scripts/not-needed.js/**`,
+		`fixtures/scripts/lint.js/**`,
+		`!fixtures/**/.DS_Store/**`,
+		`!fixtures/**/Thumbs.db/**`,
+		`!fixtures/**/node_modules/**`,
+		`!fixtures/**/package-lock.json/**`,
+		`!fixtures/**/*.tsbuildinfo/**`,
+		`!fixtures/**/dist/**`,
+		`!fixtures/**/*.dll/**`,
+		`!fixtures/**/*.exe/**`,
+		`!fixtures/**/*.cmd/**`,
+		`!fixtures/**/*.pdb/**`,
+		`!fixtures/**/*.suo/**`,
+		`!fixtures/**/*.js/**`,
+		`!fixtures/**/*.user/**`,
+		`!fixtures/**/*.cache/**`,
+		`!fixtures/**/*.cs/**`,
+		`!fixtures/**/*.sln/**`,
+		`!fixtures/**/*.csproj/**`,
+		`!fixtures/**/*.map/**`,
+		`!fixtures/**/*.swp/**`,
+		`!fixtures/**/*.code-workspace/**`,
+		`!fixtures/**/*.log/**`,
+		`!fixtures/**/_Resharper.DefinitelyTyped/**`,
+		`!fixtures/**/bin/**`,
+		`!fixtures/**/obj/**`,
+		`!fixtures/**/Properties/**`,
+		`!fixtures/**/*~/**`,
+		`!fixtures/_infrastructure/tests/build/**`,
+		`!fixtures/**/.idea/**`,
+		`!fixtures/**/*.iml/**`,
+		`!fixtures/**/*.js.map/**`,
+		`!fixtures/**/npm-debug.log/**`,
+		`!fixtures/**/.sublimets/**`,
+		`!fixtures/.settings/launch.json/**`,
+		`!fixtures/**/.vs/**`,
+		`!fixtures/**/.vscode/**`,
+		`!fixtures/**/.history/**`,
+		`!fixtures/**/yarn.lock/**`,
+		`!fixtures/**/shrinkwrap.yaml/**`,
+		`!fixtures/**/pnpm-lock.yaml/**`,
+		`!fixtures/**/pnpm-debug.log/**`,
+		`!fixtures/**/*.tgz/**`,
 	})
 
 	assert.Equal(t, GlobifyGitIgnore(gitignoreContent), []string{
@@ -284,10 +346,11 @@ pnpm-debug.log
 		`!**/.idea`,
 		`!**/*.iml`,
 		`!**/*.js.map`,
-		`..js/**`,
-		`.cripts/new-package.js`,
-		`.cripts/not-needed.js`,
-		`.cripts/lint.js`,
+		`scripts/new-package.js`,
+		`scripts/not-needed.js`,
+		`scripts/lint.js`,
+		`!**/node_modules`,
+		`!**/package-lock.json`,
 		`!**/npm-debug.log`,
 		`!**/.sublimets`,
 		`!.settings/launch.json`,
@@ -299,6 +362,10 @@ pnpm-debug.log
 		`!**/pnpm-lock.yaml`,
 		`!**/pnpm-debug.log`,
 		`!**/*.tgz`,
+		`**/*.js/**`,
+		`scripts/new-package.js/**`,
+		`scripts/not-needed.js/**`,
+		`scripts/lint.js/**`,
 		`!**/.DS_Store/**`,
 		`!**/Thumbs.db/**`,
 		`!**/node_modules/**`,
@@ -329,9 +396,6 @@ pnpm-debug.log
 		`!**/.idea/**`,
 		`!**/*.iml/**`,
 		`!**/*.js.map/**`,
-		`.cripts/new-package.js/**`,
-		`.cripts/not-needed.js/**`,
-		`.cripts/lint.js/**`,
 		`!**/npm-debug.log/**`,
 		`!**/.sublimets/**`,
 		`!.settings/launch.json/**`,
@@ -353,94 +417,355 @@ func TestGlobifyGitIgnoreFile(t *testing.T) {
 		log.Fatal(err)
 	}
 	assert.Equal(t, globs, []string{
-		`!./fixtures/**/.DS_Store`,
-		`!./fixtures/**/Thumbs.db`,
-		`!./fixtures/**/node_modules`,
-		`!./fixtures/**/package-lock.json`,
-		`!./fixtures/**/*.tsbuildinfo`,
-		`!./fixtures/**/dist`,
-		`!./fixtures/**/*.dll`,
-		`!./fixtures/**/*.exe`,
-		`!./fixtures/**/*.cmd`,
-		`!./fixtures/**/*.pdb`,
-		`!./fixtures/**/*.suo`,
-		`!./fixtures/**/*.js`,
-		`!./fixtures/**/*.user`,
-		`!./fixtures/**/*.cache`,
-		`!./fixtures/**/*.cs`,
-		`!./fixtures/**/*.sln`,
-		`!./fixtures/**/*.csproj`,
-		`!./fixtures/**/*.map`,
-		`!./fixtures/**/*.swp`,
-		`!./fixtures/**/*.code-workspace`,
-		`!./fixtures/**/*.log`,
-		`!./fixtures/**/_Resharper.DefinitelyTyped`,
-		`!./fixtures/**/bin`,
-		`!./fixtures/**/obj`,
-		`!./fixtures/**/Properties`,
-		`!./fixtures/**/*~`,
-		`!./fixtures/_infrastructure/tests/build`,
-		`!./fixtures/**/.idea`,
-		`!./fixtures/**/*.iml`,
-		`!./fixtures/**/*.js.map`,
-		`./fixtures/*.js/**`,
-		`./fixtures/scripts/new-package.js`,
-		`./fixtures/scripts/not-needed.js`,
-		`./fixtures/scripts/lint.js`,
-		`!./fixtures/**/npm-debug.log`,
-		`!./fixtures/**/.sublimets`,
-		`!./fixtures/.settings/launch.json`,
-		`!./fixtures/**/.vs`,
-		`!./fixtures/**/.vscode`,
-		`!./fixtures/**/.history`,
-		`!./fixtures/**/yarn.lock`,
-		`!./fixtures/**/shrinkwrap.yaml`,
-		`!./fixtures/**/pnpm-lock.yaml`,
-		`!./fixtures/**/pnpm-debug.log`,
-		`!./fixtures/**/*.tgz`,
-		`!./fixtures/**/.DS_Store/**`,
-		`!./fixtures/**/Thumbs.db/**`,
-		`!./fixtures/**/node_modules/**`,
-		`!./fixtures/**/package-lock.json/**`,
-		`!./fixtures/**/*.tsbuildinfo/**`,
-		`!./fixtures/**/dist/**`,
-		`!./fixtures/**/*.dll/**`,
-		`!./fixtures/**/*.exe/**`,
-		`!./fixtures/**/*.cmd/**`,
-		`!./fixtures/**/*.pdb/**`,
-		`!./fixtures/**/*.suo/**`,
-		`!./fixtures/**/*.js/**`,
-		`!./fixtures/**/*.user/**`,
-		`!./fixtures/**/*.cache/**`,
-		`!./fixtures/**/*.cs/**`,
-		`!./fixtures/**/*.sln/**`,
-		`!./fixtures/**/*.csproj/**`,
-		`!./fixtures/**/*.map/**`,
-		`!./fixtures/**/*.swp/**`,
-		`!./fixtures/**/*.code-workspace/**`,
-		`!./fixtures/**/*.log/**`,
-		`!./fixtures/**/_Resharper.DefinitelyTyped/**`,
-		`!./fixtures/**/bin/**`,
-		`!./fixtures/**/obj/**`,
-		`!./fixtures/**/Properties/**`,
-		`!./fixtures/**/*~/**`,
-		`!./fixtures/_infrastructure/tests/build/**`,
-		`!./fixtures/**/.idea/**`,
-		`!./fixtures/**/*.iml/**`,
-		`!./fixtures/**/*.js.map/**`,
-		`./fixtures/scripts/new-package.js/**`,
-		`./fixtures/scripts/not-needed.js/**`,
-		`./fixtures/scripts/lint.js/**`,
-		`!./fixtures/**/npm-debug.log/**`,
-		`!./fixtures/**/.sublimets/**`,
-		`!./fixtures/.settings/launch.json/**`,
-		`!./fixtures/**/.vs/**`,
-		`!./fixtures/**/.vscode/**`,
-		`!./fixtures/**/.history/**`,
-		`!./fixtures/**/yarn.lock/**`,
-		`!./fixtures/**/shrinkwrap.yaml/**`,
-		`!./fixtures/**/pnpm-lock.yaml/**`,
-		`!./fixtures/**/pnpm-debug.log/**`,
-		`!./fixtures/**/*.tgz/**`,
+		`!fixtures/**/.DS_Store`,
+		`!fixtures/**/Thumbs.db`,
+		`!fixtures/**/node_modules`,
+		`!fixtures/**/package-lock.json`,
+		`!fixtures/**/*.tsbuildinfo`,
+		`!fixtures/**/dist`,
+		`!fixtures/**/*.dll`,
+		`!fixtures/**/*.exe`,
+		`!fixtures/**/*.cmd`,
+		`!fixtures/**/*.pdb`,
+		`!fixtures/**/*.suo`,
+		`!fixtures/**/*.js`,
+		`!fixtures/**/*.user`,
+		`!fixtures/**/*.cache`,
+		`!fixtures/**/*.cs`,
+		`!fixtures/**/*.sln`,
+		`!fixtures/**/*.csproj`,
+		`!fixtures/**/*.map`,
+		`!fixtures/**/*.swp`,
+		`!fixtures/**/*.code-workspace`,
+		`!fixtures/**/*.log`,
+		`!fixtures/**/_Resharper.DefinitelyTyped`,
+		`!fixtures/**/bin`,
+		`!fixtures/**/obj`,
+		`!fixtures/**/Properties`,
+		`!fixtures/**/*~`,
+		`!fixtures/_infrastructure/tests/build`,
+		`!fixtures/**/.idea`,
+		`!fixtures/**/*.iml`,
+		`!fixtures/**/*.js.map`,
+		`fixtures/scripts/new-package.js`,
+		`fixtures/scripts/not-needed.js`,
+		`fixtures/scripts/lint.js`,
+		`!fixtures/**/node_modules`,
+		`!fixtures/**/package-lock.json`,
+		`!fixtures/**/npm-debug.log`,
+		`!fixtures/**/.sublimets`,
+		`!fixtures/.settings/launch.json`,
+		`!fixtures/**/.vs`,
+		`!fixtures/**/.vscode`,
+		`!fixtures/**/.history`,
+		`!fixtures/**/yarn.lock`,
+		`!fixtures/**/shrinkwrap.yaml`,
+		`!fixtures/**/pnpm-lock.yaml`,
+		`!fixtures/**/pnpm-debug.log`,
+		`!fixtures/**/*.tgz`,
+		`fixtures/**/*.js/**`,
+		`fixtures/scripts/new-package.js/**`,
+		`fixtures/scripts/not-needed.js/**`,
+		`fixtures/scripts/lint.js/**`,
+		`!fixtures/**/.DS_Store/**`,
+		`!fixtures/**/Thumbs.db/**`,
+		`!fixtures/**/node_modules/**`,
+		`!fixtures/**/package-lock.json/**`,
+		`!fixtures/**/*.tsbuildinfo/**`,
+		`!fixtures/**/dist/**`,
+		`!fixtures/**/*.dll/**`,
+		`!fixtures/**/*.exe/**`,
+		`!fixtures/**/*.cmd/**`,
+		`!fixtures/**/*.pdb/**`,
+		`!fixtures/**/*.suo/**`,
+		`!fixtures/**/*.js/**`,
+		`!fixtures/**/*.user/**`,
+		`!fixtures/**/*.cache/**`,
+		`!fixtures/**/*.cs/**`,
+		`!fixtures/**/*.sln/**`,
+		`!fixtures/**/*.csproj/**`,
+		`!fixtures/**/*.map/**`,
+		`!fixtures/**/*.swp/**`,
+		`!fixtures/**/*.code-workspace/**`,
+		`!fixtures/**/*.log/**`,
+		`!fixtures/**/_Resharper.DefinitelyTyped/**`,
+		`!fixtures/**/bin/**`,
+		`!fixtures/**/obj/**`,
+		`!fixtures/**/Properties/**`,
+		`!fixtures/**/*~/**`,
+		`!fixtures/_infrastructure/tests/build/**`,
+		`!fixtures/**/.idea/**`,
+		`!fixtures/**/*.iml/**`,
+		`!fixtures/**/*.js.map/**`,
+		`!fixtures/**/npm-debug.log/**`,
+		`!fixtures/**/.sublimets/**`,
+		`!fixtures/.settings/launch.json/**`,
+		`!fixtures/**/.vs/**`,
+		`!fixtures/**/.vscode/**`,
+		`!fixtures/**/.history/**`,
+		`!fixtures/**/yarn.lock/**`,
+		`!fixtures/**/shrinkwrap.yaml/**`,
+		`!fixtures/**/pnpm-lock.yaml/**`,
+		`!fixtures/**/pnpm-debug.log/**`,
+		`!fixtures/**/*.tgz/**`,
+	})
+}
+
+func TestGlobifyGitIgnoreWithOptions(t *testing.T) {
+	globs, err := GlobifyGitIgnoreWithOptions("*.{js,ts}\n!\nfoo[\n/dir/\n", GlobifyOptions{Directory: "root", Dialect: DialectDoublestar})
+	assert.EqualError(t, err, "line 2, column 2: \"!\": empty pattern\nline 3, column 4: \"foo[\": unterminated character class")
+	assert.Equal(t, globs, []string{
+		`!root/**/*.\{js,ts\}`,
+		`!root/**/*.\{js,ts\}/*/**`,
+		`!root/dir/*/**`,
+	})
+
+	globs, err = GlobifyGitIgnoreWithOptions("*.log\n", GlobifyOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, globs, []string{`!**/*.log`, `!**/*.log/**`})
+
+	// the columns are in the original lines
+	_, err = GlobifyGitIgnoreWithOptions("  a\n    b\\\n", GlobifyOptions{})
+	var parseError *ParseError
+	assert.True(t, errors.As(err, &parseError))
+	assert.Equal(t, *parseError, ParseError{Line: 2, Column: 6, Pattern: "b\\", Err: ErrTrailingBackslash})
+	assert.True(t, errors.Is(err, ErrTrailingBackslash))
+}
+
+func TestGlobifyGitIgnoreStrict(t *testing.T) {
+	content := "\uFEFF  # not a comment\r\n  *.log \\ \r\n!\n\tfoo[\n"
+	globs, err := GlobifyGitIgnoreWithOptions(content, GlobifyOptions{Strict: true})
+	assert.EqualError(t, err, "line 4, column 5: \"\\tfoo[\": unterminated character class")
+	assert.Equal(t, globs, []string{
+		`!**/  # not a comment`,
+		`!**/  *.log \ `,
+		`!**/  # not a comment/**`,
+		`!**/  *.log \ /**`,
+	})
+
+	// by default, the surrounding whitespace is removed
+	globs, err = GlobifyGitIgnoreWithOptions(content, GlobifyOptions{})
+	assert.EqualError(t, err, "line 3, column 2: \"!\": empty pattern\nline 4, column 5: \"foo[\": unterminated character class")
+	assert.Equal(t, globs, []string{`!**/# not a comment`, `!**/*.log  `, `!**/# not a comment/**`, `!**/*.log  /**`})
+}
+
+func TestGlobifyGitIgnoreIgnoreCase(t *testing.T) {
+	content := "*.JS\n/Build/\n[A]bc\n!Keep[a-b].js\n"
+	globs, err := GlobifyGitIgnoreWithOptions(content, GlobifyOptions{IgnoreCase: true, Dialect: DialectDoublestar})
+	assert.Nil(t, err)
+	// `[A]bc` never matches, so it has no globs
+	assert.Equal(t, globs, []string{
+		`!**/*.[jJ][sS]`,
+		`**/[kK][eE][eE][pP][ABab].[jJ][sS]`,
+		`**/[kK][eE][eE][pP][ABab].[jJ][sS]/*/**`,
+		`!**/*.[jJ][sS]/*/**`,
+		`![bB][uU][iI][lL][dD]/*/**`,
+	})
+
+	// the globby globs keep the letters for the nocase option
+	globs, err = GlobifyGitIgnoreWithOptions(content, GlobifyOptions{IgnoreCase: true})
+	assert.Nil(t, err)
+	assert.Equal(t, globs, []string{
+		`!**/*.JS`,
+		`**/Keep[ABab].js`,
+		`**/Keep[ABab].js/**`,
+		`!**/*.JS/**`,
+		`!Build/**`,
+	})
+
+	// a directory `A` does not make `/A` directory-only, as it also matches a file `a`
+	directory := t.TempDir()
+	assert.Nil(t, os.Mkdir(path.Join(directory, "A"), 0o755))
+	globs, err = GlobifyGitIgnoreWithOptions("/A\n", GlobifyOptions{Directory: directory, IgnoreCase: true, Dialect: DialectDoublestar})
+	assert.Nil(t, err)
+	assert.Equal(t, globs, []string{"!" + directory + "/[aA]", "!" + directory + "/[aA]/*/**"})
+}
+
+func TestDedent(t *testing.T) {
+	assert.Equal(t, Dedent(`
+		*.log
+		  # comment
+		dist/
+	`), "\n*.log\n  # comment\ndist/\n")
+	assert.Equal(t, Dedent("  a\n \n    b"), "a\n\n  b")
+	assert.Equal(t, Dedent("a\n  b\n"), "a\n  b\n")
+	assert.Equal(t, Dedent(""), "")
+}
+
+func TestGlobifyGitIgnoreEntryWithOptions(t *testing.T) {
+	globs, err := GlobifyGitIgnoreEntryWithOptions("!*.js", GlobifyOptions{Directory: "root"})
+	assert.Nil(t, err)
+	assert.Equal(t, globs, []string{`root/**/*.js`, `root/**/*.js/**`})
+
+	for _, entry := range []string{"", "!", "/", "!/"} {
+		globs, err = GlobifyGitIgnoreEntryWithOptions(entry, GlobifyOptions{})
+		assert.Nil(t, globs)
+		assert.True(t, errors.Is(err, ErrEmptyPattern), entry)
+	}
+
+	_, err = GlobifyGitIgnoreEntryWithOptions("!a/[[:foo:]]/", GlobifyOptions{})
+	assert.EqualError(t, err, "column 5: \"!a/[[:foo:]]/\": unknown character class [:foo:]")
+	assert.True(t, errors.Is(err, ErrUnknownCharacterClass))
+}
+
+func TestProbePathType(t *testing.T) {
+	directory := t.TempDir()
+	assert.Nil(t, os.WriteFile(path.Join(directory, "file"), []byte{}, 0o644))
+
+	pathType, err := ProbePathType(directory)
+	assert.Equal(t, pathType, PathTypeDirectory)
+	assert.Nil(t, err)
+	pathType, err = ProbePathType(path.Join(directory, "file"))
+	assert.Equal(t, pathType, PathTypeFile)
+	assert.Nil(t, err)
+
+	// a missing path is not an error
+	pathType, err = ProbePathType(path.Join(directory, "missing"))
+	assert.Equal(t, pathType, PathTypeOther)
+	assert.Nil(t, err)
+	pathType, err = ProbePathType(path.Join(directory, "file", "child"))
+	assert.Equal(t, pathType, PathTypeOther)
+	assert.Nil(t, err)
+
+	pathType, err = ProbePathType("bad\x00path")
+	assert.Equal(t, pathType, PathTypeOther)
+	var probeError *PathProbeError
+	assert.True(t, errors.As(err, &probeError))
+	assert.Equal(t, probeError.Path, "bad\x00path")
+}
+
+func TestProbePathTypeSymlinks(t *testing.T) {
+	directory := t.TempDir()
+	assert.Nil(t, os.Mkdir(path.Join(directory, "dir"), 0o755))
+	if err := os.Symlink("dir", path.Join(directory, "link")); err != nil {
+		t.Skip("symbolic links are not supported:", err)
+	}
+	assert.Nil(t, os.Symlink("missing", path.Join(directory, "broken")))
+
+	assert.Equal(t, GetPathType(path.Join(directory, "link")), PathTypeSymlink)
+	assert.Equal(t, GetPathType(path.Join(directory, "link"), true), PathTypeDirectory)
+	assert.Equal(t, GetPathType(path.Join(directory, "broken"), true), PathTypeSymlink)
+
+	// like git, a link to a directory is converted like a file by default
+	globs, err := GlobifyGitIgnoreWithOptions("/link\n/broken\n", GlobifyOptions{Directory: directory})
+	assert.Nil(t, err)
+	assert.Equal(t, globs, []string{"!" + directory + "/link", "!" + directory + "/broken"})
+	globs, err = GlobifyGitIgnoreWithOptions("/link\n/broken\n", GlobifyOptions{Directory: directory, FollowSymlinks: true})
+	assert.Nil(t, err)
+	assert.Equal(t, globs, []string{"!" + directory + "/broken", "!" + directory + "/link/**"})
+}
+
+func TestPathTypeOfMode(t *testing.T) {
+	assert.Equal(t, PathTypeOfMode(0o644), PathTypeFile)
+	assert.Equal(t, PathTypeOfMode(fs.ModeDir), PathTypeDirectory)
+	assert.Equal(t, PathTypeOfMode(fs.ModeSymlink), PathTypeSymlink)
+	assert.Equal(t, PathTypeOfMode(fs.ModeNamedPipe), PathTypeNamedPipe)
+	assert.Equal(t, PathTypeOfMode(fs.ModeSocket), PathTypeSocket)
+	assert.Equal(t, PathTypeOfMode(fs.ModeDevice|fs.ModeCharDevice), PathTypeDevice)
+	assert.Equal(t, PathTypeOfMode(fs.ModeIrregular), PathTypeOther)
+}
+
+func TestPathTypeJSON(t *testing.T) {
+	assert.Equal(t, PathTypeNamedPipe.String(), "named-pipe")
+	assert.Equal(t, PathType(9).String(), "PathType(9)")
+
+	encoded, err := json.Marshal(map[string]PathType{"a": PathTypeDirectory, "b": PathTypeSocket})
+	assert.Nil(t, err)
+	assert.Equal(t, string(encoded), `{"a":"directory","b":"socket"}`)
+	_, err = json.Marshal(PathType(9))
+	assert.NotNil(t, err)
+
+	var decoded []PathType
+	assert.Nil(t, json.Unmarshal([]byte(`["symlink","device","other"]`), &decoded))
+	assert.Equal(t, decoded, []PathType{PathTypeSymlink, PathTypeDevice, PathTypeOther})
+	err = json.Unmarshal([]byte(`["fifo"]`), &decoded)
+	assert.EqualError(t, err, `unknown path type "fifo" (expected one of file, directory, other, symlink, named-pipe, socket, device)`)
+}
+
+/** Changes the working directory until the end of the test */
+func chdir(t *testing.T, directory string) {
+	previous, err := os.Getwd()
+	assert.Nil(t, err)
+	assert.Nil(t, os.Chdir(directory))
+	t.Cleanup(func() {
+		os.Chdir(previous)
+	})
+}
+
+func TestGlobifyPath(t *testing.T) {
+	directory := t.TempDir()
+	assert.Nil(t, os.Mkdir(path.Join(directory, "dir"), 0o755))
+	chdir(t, directory)
+
+	// the current working directory is used to check the type
+	assert.Equal(t, GlobifyPath("/dir"), []string{`!` + directory + `/dir/**`})
+	assert.Equal(t, GlobifyPath("/dir", "/missing"), []string{`!/missing/dir`, `!/missing/dir/**`})
+}
+
+/** A generated ignore file with the given number of lines */
+func generatedGitIgnore(lines int) string {
+	templates := []string{
+		"# generated section %d",
+		"",
+		"*.%d.log",
+		"build-%d/",
+		"/dist/chunk-%d.js",
+		"!keep-%d.log",
+		"  src/**/gen_%d_*.go  ",
+		"docs/%d/[abc]?.md",
+		"trailing-%d\\ ",
+	}
+	var builder strings.Builder
+	for i := 0; i < lines; i++ {
+		fmt.Fprintf(&builder, templates[i%len(templates)], i)
+		builder.WriteByte('\n')
+	}
+	return builder.String()
+}
+
+func BenchmarkGlobifyGitIgnore(b *testing.B) {
+	for _, lines := range []int{10000, 100000} {
+		content := generatedGitIgnore(lines)
+		b.Run(fmt.Sprintf("%dLines", lines), func(b *testing.B) {
+			b.SetBytes(int64(len(content)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				GlobifyGitIgnore(content, "root")
+			}
+		})
+	}
+}
+
+func FuzzGlobifyGitIgnoreEntry(f *testing.F) {
+	for _, entry := range []string{"", "!", "/", "//", "dir/", "/dir/", "!*.js", "a/**/b", "**/", "[[:alpha:]]*.{js,ts}", "\\#x", "/!a", "[]]", "[^a]"} {
+		f.Add(entry)
+	}
+	f.Fuzz(func(t *testing.T, entry string) {
+		negated := strings.HasPrefix(entry, "!")
+		wellFormed := CheckGitIgnorePattern(strings.TrimSuffix(strings.TrimPrefix(entry, "!"), "/")) == nil
+		for _, dialect := range []Dialect{DialectGlobby, DialectDoublestar} {
+			globs, _ := globifyGitIgnoreEntry(entry, GlobifyOptions{Dialect: dialect}, "")
+			if !wellFormed {
+				continue
+			}
+			if len(globs) > 2 {
+				t.Fatalf("%q: %d globs", entry, len(globs))
+			}
+			for _, glob := range globs {
+				// the `!` of the list marks the ignored paths, so the glob itself cannot start with `!`
+				if !negated {
+					if !strings.HasPrefix(glob, "!") {
+						t.Fatalf("%q: %s: %q is not negated", entry, dialect, glob)
+					}
+					glob = glob[1:]
+				}
+				if strings.HasPrefix(glob, "!") {
+					t.Fatalf("%q: %s: %q starts with !", entry, dialect, glob)
+				}
+				if err := checkGlob(glob, dialect); err != nil {
+					t.Fatalf("%q: %s: %q: %v", entry, dialect, glob, err)
+				}
+			}
+		}
 	})
 }
@@ -0,0 +1,150 @@
+package lib
+
+import (
+	"strings"
+)
+
+/**
+ * An immutable list of gitignore patterns that decides which paths are ignored.
+ *
+ * Like git, the last matching pattern wins, and nothing inside an ignored directory can be re-included. The patterns
+ * of the nested ignore files should come after the patterns of their parents. A nil `*Matcher` ignores nothing.
+ */
+type Matcher struct {
+	patterns      []Pattern
+	ignoreCase    bool
+	normalization Normalization
+}
+
+/**
+ * Creates a matcher from the given patterns
+ *
+ * @param {[]Pattern} patterns The patterns in the order of precedence (the last one wins)
+ * @returns {*Matcher}
+ */
+func NewMatcher(patterns []Pattern) *Matcher {
+	return &Matcher{patterns: append([]Pattern{}, patterns...)}
+}
+
+/**
+ * Returns a new matcher that has the given patterns after the patterns of this matcher. The receiver is not modified.
+ *
+ * @param {[]Pattern} patterns The patterns to add (e.g. the patterns of a nested `.gitignore`)
+ * @returns {*Matcher}
+ */
+func (matcher *Matcher) Append(patterns []Pattern) *Matcher {
+	if len(patterns) == 0 && matcher != nil {
+		return matcher
+	}
+	combined := make([]Pattern, 0, len(matcher.Patterns())+len(patterns))
+	combined = append(combined, matcher.Patterns()...)
+	normalization := matcher.Normalization()
+	for _, pattern := range patterns {
+		combined = append(combined, normalization.normalizePattern(pattern))
+	}
+	return &Matcher{patterns: combined, ignoreCase: matcher.IgnoreCase(), normalization: normalization}
+}
+
+/**
+ * Returns a new matcher that has the same patterns and compares the ASCII letters case-insensitively (like git with
+ * `core.ignoreCase`) or not. The matchers created by `Append` keep this setting. The receiver is not modified.
+ *
+ * @param {bool} ignoreCase Whether the matching is case-insensitive
+ * @returns {*Matcher}
+ */
+func (matcher *Matcher) WithIgnoreCase(ignoreCase bool) *Matcher {
+	return &Matcher{patterns: matcher.Patterns(), ignoreCase: ignoreCase, normalization: matcher.Normalization()}
+}
+
+/** Is the matching case-insensitive? */
+func (matcher *Matcher) IgnoreCase() bool {
+	return matcher != nil && matcher.ignoreCase
+}
+
+/**
+ * Returns a new matcher that converts its patterns and the matched paths to the given Unicode normalization form (e.g.
+ * so that the composed `é` of a `.gitignore` matches the decomposed names of macOS). The matchers created by `Append`
+ * keep this setting. The receiver is not modified.
+ *
+ * @param {Normalization} normalization The normalization form
+ * @returns {*Matcher}
+ */
+func (matcher *Matcher) WithNormalization(normalization Normalization) *Matcher {
+	patterns := make([]Pattern, 0, len(matcher.Patterns()))
+	for _, pattern := range matcher.Patterns() {
+		patterns = append(patterns, normalization.normalizePattern(pattern))
+	}
+	return &Matcher{patterns: patterns, ignoreCase: matcher.IgnoreCase(), normalization: normalization}
+}
+
+/** The Unicode normalization form of the matching */
+func (matcher *Matcher) Normalization() Normalization {
+	if matcher == nil {
+		return NormalizationNone
+	}
+	return matcher.normalization
+}
+
+/** The patterns of the matcher */
+func (matcher *Matcher) Patterns() []Pattern {
+	if matcher == nil {
+		return nil
+	}
+	return matcher.patterns
+}
+
+/**
+ * Get the last pattern that matches the path itself. The parent directories are not considered.
+ *
+ * @param {string} name The posix path relative to the root of the matching
+ * @param {bool} isDir Whether the path is a directory
+ * @returns {*Pattern} The pattern or nil if no pattern matches
+ */
+func (matcher *Matcher) MatchingPattern(name string, isDir bool) *Pattern {
+	if matcher == nil {
+		return nil
+	}
+	name = matcher.normalization.Normalize(name)
+	flags := WildmatchFlags(0)
+	if matcher.ignoreCase {
+		flags = WildmatchCaseFold
+	}
+	for iPattern := len(matcher.patterns) - 1; iPattern >= 0; iPattern-- {
+		if matcher.patterns[iPattern].match(name, isDir, flags) {
+			return &matcher.patterns[iPattern]
+		}
+	}
+	return nil
+}
+
+/**
+ * Get the pattern that decides whether the path is ignored. If a parent directory is ignored, its pattern is returned.
+ *
+ * @param {string} name The posix path relative to the root of the matching
+ * @param {bool} isDir Whether the path is a directory
+ * @returns {*Pattern} The pattern or nil if no pattern matches. A negated pattern means the path is not ignored.
+ */
+func (matcher *Matcher) LastMatchingPattern(name string, isDir bool) *Pattern {
+	name = strings.Trim(PosixifyPath(name), "/")
+	for iSlash := 0; iSlash < len(name); iSlash++ {
+		if name[iSlash] != '/' {
+			continue
+		}
+		if pattern := matcher.MatchingPattern(name[:iSlash], true); pattern != nil && !pattern.Negated {
+			return pattern
+		}
+	}
+	return matcher.MatchingPattern(name, isDir)
+}
+
+/**
+ * Is the given path ignored?
+ *
+ * @param {string} name The posix path relative to the root of the matching
+ * @param {bool} isDir Whether the path is a directory
+ * @returns {bool}
+ */
+func (matcher *Matcher) Ignored(name string, isDir bool) bool {
+	pattern := matcher.LastMatchingPattern(name, isDir)
+	return pattern != nil && !pattern.Negated
+}
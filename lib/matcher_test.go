@@ -0,0 +1,79 @@
+package lib
+
+import (
+	"io/fs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatcher(t *testing.T) {
+	matcher := NewMatcher(ParseGitIgnore(`*.log
+!important.log
+build/
+/root_only
+`))
+	assert.Equal(t, matcher.Ignored("debug.log", false), true)
+	assert.Equal(t, matcher.Ignored("logs/debug.log", false), true)
+	assert.Equal(t, matcher.Ignored("important.log", false), false)
+	assert.Equal(t, matcher.Ignored("build", true), true)
+	assert.Equal(t, matcher.Ignored("build", false), false)
+	assert.Equal(t, matcher.Ignored("root_only", false), true)
+	assert.Equal(t, matcher.Ignored("sub/root_only", false), false)
+
+	// nothing inside an ignored directory can be re-included
+	assert.Equal(t, matcher.Ignored("build/important.log", false), true)
+	assert.Equal(t, matcher.LastMatchingPattern("build/important.log", false).String(), "build/")
+	assert.Equal(t, matcher.LastMatchingPattern("important.log", false).String(), "!important.log")
+	assert.Nil(t, matcher.LastMatchingPattern("main.go", false))
+}
+
+func TestMatcherIgnoreCase(t *testing.T) {
+	matcher := NewMatcher(ParseGitIgnore("*.LOG\n/Build/\n"))
+	assert.Equal(t, matcher.Ignored("debug.log", false), false)
+
+	folded := matcher.WithIgnoreCase(true)
+	assert.Equal(t, folded.IgnoreCase(), true)
+	assert.Equal(t, folded.Ignored("debug.log", false), true)
+	assert.Equal(t, folded.Ignored("BUILD/out.bin", false), true)
+	assert.Equal(t, matcher.IgnoreCase(), false)
+
+	// the appended matchers keep the setting
+	assert.Equal(t, folded.Append(ParseGitIgnore("!KEEP.log\n")).Ignored("keep.log", false), false)
+
+	var empty *Matcher
+	assert.Equal(t, empty.WithIgnoreCase(true).IgnoreCase(), true)
+}
+
+func TestMatcherAppend(t *testing.T) {
+	root := NewMatcher(ParseGitIgnore("*.log\n"))
+	nested := root.Append(ParseGitIgnore("!keep.log\n", "sub"))
+
+	assert.Equal(t, root.Ignored("sub/keep.log", false), true)
+	assert.Equal(t, nested.Ignored("sub/keep.log", false), false)
+	assert.Equal(t, nested.Ignored("keep.log", false), true)
+	assert.Equal(t, len(root.Patterns()), 1)
+	assert.Equal(t, len(nested.Patterns()), 2)
+
+	var empty *Matcher
+	assert.Equal(t, empty.Ignored("a", false), false)
+	assert.Equal(t, empty.Append(ParseGitIgnore("a\n")).Ignored("a", false), true)
+}
+
+func FuzzMatcher(f *testing.F) {
+	f.Add("*.log\n!keep.log\n", "a/keep.log", false)
+	f.Add("build/\n!build/keep\n", "build", true)
+	f.Add("/a/**/b\n", "a/x/y/b", true)
+	f.Fuzz(func(t *testing.T, content string, name string, isDir bool) {
+		matcher := NewMatcher(ParseGitIgnore(content))
+		pattern := matcher.LastMatchingPattern(name, isDir)
+		ignored := matcher.Ignored(name, isDir)
+		if ignored != (pattern != nil && !pattern.Negated) {
+			t.Fatalf("Ignored is %t, but the last matching pattern is %v", ignored, pattern)
+		}
+		// nothing inside an ignored directory can be re-included
+		if ignored && isDir && fs.ValidPath(name) && name != "." && !matcher.Ignored(name+"/child", false) {
+			t.Fatalf("%q is ignored, but not its child", name)
+		}
+	})
+}
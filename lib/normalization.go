@@ -0,0 +1,120 @@
+package lib
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+/**
+ * The Unicode normalization form of the patterns and the paths. The same accented name can be composed (NFC, e.g. `é`
+ * as one code point) or decomposed (NFD, e.g. `e` and a combining accent). macOS returns the decomposed names, while
+ * most editors write the composed ones, so the patterns may not match the paths unless both are normalized.
+ */
+type Normalization uint
+
+const (
+	/** The patterns and the paths are compared byte by byte (the default, like git) */
+	NormalizationNone Normalization = iota
+	/** The composed form. It is what git uses with `core.precomposeUnicode` (see `ReadGitPrecomposeUnicode`). */
+	NormalizationNFC
+	/** The decomposed form that the file systems of macOS return */
+	NormalizationNFD
+)
+
+/** The names of the normalization forms */
+var normalizationNames = []string{
+	NormalizationNone: "none",
+	NormalizationNFC:  "nfc",
+	NormalizationNFD:  "nfd",
+}
+
+/** The name of the normalization form */
+func (normalization Normalization) String() string {
+	if int(normalization) < len(normalizationNames) {
+		return normalizationNames[normalization]
+	}
+	return fmt.Sprintf("Normalization(%d)", uint(normalization))
+}
+
+/**
+ * Get the normalization form with the given name
+ *
+ * @param {string} name The name of the form (`none`, `nfc` or `nfd`)
+ * @returns {(Normalization, error)} The form or an error if the name is unknown
+ */
+func ParseNormalization(name string) (Normalization, error) {
+	for iNormalization, normalizationName := range normalizationNames {
+		if normalizationName == name {
+			return Normalization(iNormalization), nil
+		}
+	}
+	return NormalizationNone, fmt.Errorf("unknown normalization %q (expected one of %s)", name, strings.Join(normalizationNames, ", "))
+}
+
+/**
+ * Converts a string to the normalization form
+ *
+ * @param {string} str A pattern or a path
+ * @returns {string} The normalized string. It is returned as is for `NormalizationNone`.
+ */
+func (normalization Normalization) Normalize(str string) string {
+	var form norm.Form
+	switch normalization {
+	case NormalizationNFC:
+		form = norm.NFC
+	case NormalizationNFD:
+		form = norm.NFD
+	default:
+		return str
+	}
+	if isASCII(str) {
+		// the ASCII strings are in all the forms
+		return str
+	}
+	return form.String(str)
+}
+
+/** Normalizes the text and the base of the pattern */
+func (normalization Normalization) normalizePattern(pattern Pattern) Pattern {
+	pattern.Text = normalization.Normalize(pattern.Text)
+	pattern.Base = normalization.Normalize(pattern.Base)
+	return pattern
+}
+
+func isASCII(str string) bool {
+	for i := 0; i < len(str); i++ {
+		if str[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+/**
+ * Reads `core.precomposeUnicode` from the config of a git repository. If it is true, git converts the decomposed paths
+ * to `NormalizationNFC`.
+ *
+ * @param {string} gitDirectory The `.git` directory, or a `.git` file that points to it (e.g. in a worktree)
+ * @returns {(bool, bool, error)} The value, whether it is set, and the error of reading the config
+ */
+func ReadGitPrecomposeUnicode(gitDirectory string) (bool, bool, error) {
+	return readGitConfigBool(gitDirectory, "core", "precomposeunicode")
+}
+
+/**
+ * Get the normalization that git uses for a repository: `NormalizationNFC` if `core.precomposeUnicode` is set, and
+ * `NormalizationNone` otherwise.
+ *
+ * @param {string} repository The working tree of the repository (the directory that has `.git`)
+ * @returns {(Normalization, error)}
+ */
+func DetectNormalization(repository string) (Normalization, error) {
+	precompose, _, err := ReadGitPrecomposeUnicode(filepath.Join(repository, ".git"))
+	if err != nil || !precompose {
+		return NormalizationNone, err
+	}
+	return NormalizationNFC, nil
+}
@@ -0,0 +1,97 @@
+package lib
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/** The same name in the composed and the decomposed forms */
+const (
+	composedName   = "caf\u00e9"
+	decomposedName = "café"
+)
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, NormalizationNFC.Normalize(decomposedName), composedName)
+	assert.Equal(t, NormalizationNFD.Normalize(composedName), decomposedName)
+	assert.Equal(t, NormalizationNone.Normalize(decomposedName), decomposedName)
+	assert.Equal(t, NormalizationNFD.Normalize("*.log"), "*.log")
+
+	normalization, err := ParseNormalization("nfd")
+	assert.Nil(t, err)
+	assert.Equal(t, normalization, NormalizationNFD)
+	assert.Equal(t, normalization.String(), "nfd")
+	_, err = ParseNormalization("NFKC")
+	assert.EqualError(t, err, `unknown normalization "NFKC" (expected one of none, nfc, nfd)`)
+	assert.Equal(t, Normalization(7).String(), "Normalization(7)")
+}
+
+func TestMatcherNormalization(t *testing.T) {
+	matcher := NewMatcher(ParseGitIgnore("/" + composedName + "/*\n"))
+	// git compares the bytes
+	assert.Equal(t, matcher.Ignored(decomposedName+"/a", false), false)
+	assert.Equal(t, matcher.Ignored(composedName+"/a", false), true)
+
+	for _, normalization := range []Normalization{NormalizationNFC, NormalizationNFD} {
+		normalized := matcher.WithNormalization(normalization)
+		assert.Equal(t, normalized.Normalization(), normalization)
+		assert.Equal(t, normalized.Ignored(decomposedName+"/a", false), true)
+		assert.Equal(t, normalized.Ignored(composedName+"/a", false), true)
+		// the appended patterns are normalized too
+		nested := normalized.Append(ParseGitIgnore("!"+composedName+".txt\n", decomposedName))
+		assert.Equal(t, nested.Ignored(composedName+"/"+decomposedName+".txt", false), false)
+		assert.Equal(t, nested.WithIgnoreCase(true).Normalization(), normalization)
+	}
+}
+
+func TestIgnoreTreeNormalization(t *testing.T) {
+	// the names are decomposed like on macOS, and the ignore files are composed
+	fsys := fstest.MapFS{
+		".gitignore":                                     {Data: []byte(composedName + ".txt\n")},
+		decomposedName + "/.gitignore":                   {Data: []byte("/" + composedName + "/\n")},
+		decomposedName + ".txt":                          {Data: []byte{}},
+		decomposedName + "/a.txt":                        {Data: []byte{}},
+		decomposedName + "/" + decomposedName + "/b.txt": {Data: []byte{}},
+	}
+	assert.Equal(t, walkPaths(t, fsys, ".", WalkOptions{Hidden: true, Normalization: NormalizationNFC}), []string{
+		".",
+		".gitignore",
+		decomposedName,
+		decomposedName + "/.gitignore",
+		decomposedName + "/a.txt",
+	})
+	assert.Equal(t, len(walkPaths(t, fsys, ".", WalkOptions{Hidden: true})), 8)
+}
+
+func TestGlobifyGitIgnoreNormalization(t *testing.T) {
+	content := composedName + "/\n!" + decomposedName + ".txt\n"
+	globs, err := GlobifyGitIgnoreWithOptions(content, GlobifyOptions{Directory: composedName, Normalization: NormalizationNFD})
+	assert.Nil(t, err)
+	assert.Equal(t, globs, []string{
+		decomposedName + "/**/" + decomposedName + ".txt",
+		decomposedName + "/**/" + decomposedName + ".txt/**",
+		"!" + decomposedName + "/**/" + decomposedName + "/**",
+	})
+
+	globs, err = GlobifyGitIgnoreWithOptions(content, GlobifyOptions{Normalization: NormalizationNFC})
+	assert.Nil(t, err)
+	assert.Equal(t, globs, []string{"**/" + composedName + ".txt", "**/" + composedName + ".txt/**", "!**/" + composedName + "/**"})
+}
+
+func TestDetectNormalization(t *testing.T) {
+	directory := t.TempDir()
+	normalization, err := DetectNormalization(directory)
+	assert.Nil(t, err)
+	assert.Equal(t, normalization, NormalizationNone)
+
+	writeFiles(t, directory, map[string]string{".git/config": "[core]\n\tprecomposeUnicode = true\n"})
+	normalization, err = DetectNormalization(directory)
+	assert.Nil(t, err)
+	assert.Equal(t, normalization, NormalizationNFC)
+
+	writeFiles(t, directory, map[string]string{".git/config": "[core]\n\tprecomposeunicode = 2\n"})
+	_, err = DetectNormalization(directory)
+	assert.EqualError(t, err, "bad boolean config value '2' for 'core.precomposeunicode'")
+}
@@ -0,0 +1,292 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+/**
+ * The patterns that npm applies before the ignore files of a package. The ignore files can re-include these paths
+ * (e.g. with `!.npmrc`).
+ */
+const npmDefaultIgnore = `.npmignore
+.gitignore
+**/.svn
+**/.hg
+**/CVS
+/.lock-wscript
+/.wafpickle-*
+/build/config.gypi
+npm-debug.log
+**/.npmrc
+.*.swp
+.DS_Store
+._*
+*.orig
+/package-lock.json
+/yarn.lock
+/pnpm-lock.yaml
+/archived-packages/
+`
+
+/** The source of the default patterns of npm */
+const npmDefaultSource = "(npm defaults)"
+
+/**
+ * The per-directory ignore files of npm in the order of precedence. Only the first one that exists in a directory is
+ * read.
+ */
+var npmIgnoreFileNames = []string{".npmignore", gitIgnoreFileName}
+
+/** The fields of `package.json` that decide the files of a package */
+type npmManifest struct {
+	Files []string `json:"files"`
+	Main  string   `json:"main"`
+	/** A path, or an object of paths by the names of the commands */
+	Bin interface{} `json:"bin"`
+}
+
+/**
+ * Lists the files that `npm pack` would include in the package of a directory, without running npm:
+ * - The `.npmignore` of each directory applies to it like a `.gitignore`. If a directory has no `.npmignore`, its
+ *   `.gitignore` is used instead. The default patterns of npm (e.g. `.DS_Store` or `/package-lock.json`) apply first.
+ * - If `package.json` has `files`, only the matching files and the files of the matching directories are included. The
+ *   entries are relative to the root, and an entry that starts with `!` excludes its files. The ignore file of the root
+ *   does not apply then, but the ones of the subdirectories do.
+ * - `package.json`, the `README`, `LICENSE`, `LICENCE` and `COPYING` files of the root (in any case and with any
+ *   extension), and the files of `main` and `bin` are always included.
+ * - The `.git` and the `node_modules` directories, and the symbolic links are never included.
+ *
+ * @param {fs.FS} fsys The file system
+ * @param {string} root The directory of the package, which has a `package.json`
+ * @returns {([]string, error)} The sorted posix paths of the files relative to root, or the error of reading
+ *   `package.json` or a directory
+ */
+func NpmPackFiles(fsys fs.FS, root string) ([]string, error) {
+	content, err := fs.ReadFile(fsys, path.Join(root, "package.json"))
+	if err != nil {
+		return nil, err
+	}
+	manifest := npmManifest{}
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return nil, fmt.Errorf("%s: %w", path.Join(root, "package.json"), err)
+	}
+
+	defaults := ParseGitIgnore(npmDefaultIgnore)
+	for iPattern := range defaults {
+		defaults[iPattern].Source = npmDefaultSource
+	}
+	packer := &npmPacker{fsys: fsys, root: root, files: npmFilesMatcher(manifest.Files)}
+	files, err := packer.list("", NewMatcher(defaults))
+	if err != nil {
+		return nil, err
+	}
+
+	// the files that are always included, even if they are ignored
+	always := []string{}
+	if entries, err := fs.ReadDir(fsys, root); err == nil {
+		for _, entry := range entries {
+			if entry.Type().IsRegular() && isNpmAlwaysIncluded(entry.Name()) {
+				always = append(always, entry.Name())
+			}
+		}
+	}
+	for _, file := range append(npmBinFiles(manifest.Bin), manifest.Main) {
+		file = cleanBase(file)
+		if file == "" || strings.HasPrefix(file, "../") || isNpmNeverIncluded(file) {
+			continue
+		}
+		if info, err := fs.Stat(fsys, path.Join(root, file)); err == nil && info.Mode().IsRegular() {
+			always = append(always, file)
+		}
+	}
+
+	files = unique(append(files, always...))
+	sort.Strings(files)
+	return files, nil
+}
+
+/** The files of the `bin` field of `package.json` */
+func npmBinFiles(bin interface{}) []string {
+	switch bin := bin.(type) {
+	case string:
+		return []string{bin}
+	case map[string]interface{}:
+		files := []string{}
+		for _, file := range bin {
+			if file, ok := file.(string); ok {
+				files = append(files, file)
+			}
+		}
+		return files
+	default:
+		return nil
+	}
+}
+
+/**
+ * Is the file of the root always included (e.g. `README.md` or `licence`)? Like npm, the backups (e.g. `README.md~`) are
+ * not.
+ */
+func isNpmAlwaysIncluded(name string) bool {
+	lower := strings.ToLower(name)
+	if lower == "package.json" {
+		return true
+	}
+	for _, prefix := range []string{"readme", "license", "licence", "copying"} {
+		if lower == prefix || (strings.HasPrefix(lower, prefix+".") && !strings.HasSuffix(lower, "~") && !strings.HasSuffix(lower, "$")) {
+			return true
+		}
+	}
+	return false
+}
+
+/** Is the path in a `.git` or a `node_modules` directory? */
+func isNpmNeverIncluded(name string) bool {
+	for _, component := range strings.Split(name, "/") {
+		if component == ".git" || component == "node_modules" {
+			return true
+		}
+	}
+	return false
+}
+
+/**
+ * Converts the `files` of `package.json` to a matcher whose non-negated patterns match the included paths. An entry
+ * also matches the paths below it. It is nil if there is no `files`.
+ */
+func npmFilesMatcher(files []string) *Matcher {
+	if files == nil {
+		return nil
+	}
+	patterns := []Pattern{}
+	for _, entry := range files {
+		negated := strings.HasPrefix(entry, "!")
+		entry = strings.Trim(strings.TrimPrefix(strings.TrimPrefix(entry, "!"), "./"), "/")
+		if entry == "" || CheckGitIgnorePattern(entry) != nil {
+			continue
+		}
+		for _, text := range []string{"/" + entry, "/" + entry + "/**"} {
+			patterns = append(patterns, Pattern{Text: text, Negated: negated, Anchored: true, Source: "package.json"})
+		}
+	}
+	return NewMatcher(patterns)
+}
+
+/** Lists the files of a package */
+type npmPacker struct {
+	fsys fs.FS
+	root string
+	/** The matcher of `files`, or nil */
+	files *Matcher
+}
+
+/**
+ * Lists the files of a directory
+ *
+ * @param {string} directory The posix directory relative to the root. Empty for the root.
+ * @param {*Matcher} matcher The patterns of the parent directories
+ * @returns {([]string, error)}
+ */
+func (packer *npmPacker) list(directory string, matcher *Matcher) ([]string, error) {
+	entries, err := fs.ReadDir(packer.fsys, path.Join(packer.root, directory))
+	if err != nil {
+		return nil, err
+	}
+	if directory != "" || packer.files == nil {
+		patterns, err := packer.readIgnoreFile(directory, entries)
+		if err != nil {
+			return nil, err
+		}
+		matcher = matcher.Append(patterns)
+	}
+
+	files := []string{}
+	for _, entry := range entries {
+		name := path.Join(directory, entry.Name())
+		isDir := entry.IsDir()
+		if entry.Name() == ".git" || entry.Name() == "node_modules" || !(isDir || entry.Type().IsRegular()) {
+			continue
+		}
+		if packer.files != nil && !packer.inFiles(name, isDir) {
+			continue
+		}
+		if pattern := matcher.MatchingPattern(name, isDir); pattern != nil && !pattern.Negated {
+			continue
+		}
+		if !isDir {
+			files = append(files, name)
+			continue
+		}
+		children, err := packer.list(name, matcher)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, children...)
+	}
+	return files, nil
+}
+
+/** Reads the first ignore file of npm that exists in the directory */
+func (packer *npmPacker) readIgnoreFile(directory string, entries []fs.DirEntry) ([]Pattern, error) {
+	for _, ignoreFileName := range npmIgnoreFileNames {
+		for _, entry := range entries {
+			if entry.Name() != ignoreFileName || entry.IsDir() {
+				continue
+			}
+			source := path.Join(directory, ignoreFileName)
+			content, err := fs.ReadFile(packer.fsys, path.Join(packer.root, source))
+			if err != nil {
+				return nil, err
+			}
+			patterns := ParseGitIgnore(string(content), directory)
+			for iPattern := range patterns {
+				patterns[iPattern].Source = source
+			}
+			return patterns, nil
+		}
+	}
+	return nil, nil
+}
+
+/**
+ * Is the path selected by `files`? A directory is also selected if an entry could match a path below it (e.g. `lib`
+ * for `lib/*.js`).
+ */
+func (packer *npmPacker) inFiles(name string, isDir bool) bool {
+	// the entries also match the paths below them, so the parent directories are not checked
+	if pattern := packer.files.MatchingPattern(name, isDir); pattern != nil {
+		return !pattern.Negated
+	}
+	if !isDir {
+		return false
+	}
+	for _, pattern := range packer.files.Patterns() {
+		if !pattern.Negated && couldMatchBelow(strings.TrimPrefix(pattern.Text, "/"), name) {
+			return true
+		}
+	}
+	return false
+}
+
+/** Could the anchored pattern match a path below the directory? Its segments are matched one by one until a `**`. */
+func couldMatchBelow(pattern string, directory string) bool {
+	patternSegments := strings.Split(pattern, "/")
+	directorySegments := strings.Split(directory, "/")
+	for iSegment, segment := range directorySegments {
+		if iSegment >= len(patternSegments)-1 {
+			return false
+		}
+		if patternSegments[iSegment] == "**" {
+			return true
+		}
+		if !Wildmatch(patternSegments[iSegment], segment, 0) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,98 @@
+package lib
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/** A package with the given package.json, ignore files and the usual files */
+func npmPackage(packageJSON string, files map[string]string) fstest.MapFS {
+	fsys := fstest.MapFS{
+		"pkg/package.json":          {Data: []byte(packageJSON)},
+		"pkg/README.md":             {},
+		"pkg/LICENSE":               {},
+		"pkg/readme.md~":            {},
+		"pkg/index.js":              {},
+		"pkg/lib/a.js":              {},
+		"pkg/lib/a.test.js":         {},
+		"pkg/lib/util/b.js":         {},
+		"pkg/lib/util/b.d.ts":       {},
+		"pkg/docs/guide.md":         {},
+		"pkg/bin/cli.js":            {},
+		"pkg/.git/HEAD":             {},
+		"pkg/node_modules/x/a.js":   {},
+		"pkg/lib/node_modules/y.js": {},
+		"pkg/.DS_Store":             {},
+		"pkg/package-lock.json":     {},
+		"pkg/link.js":               {Mode: fs.ModeSymlink},
+	}
+	for name, content := range files {
+		fsys["pkg/"+name] = &fstest.MapFile{Data: []byte(content)}
+	}
+	return fsys
+}
+
+func TestNpmPackFiles(t *testing.T) {
+	cases := []struct {
+		name        string
+		packageJSON string
+		files       map[string]string
+		expected    []string
+	}{
+		{
+			"no ignore file",
+			`{}`, nil,
+			[]string{"LICENSE", "README.md", "bin/cli.js", "docs/guide.md", "index.js", "lib/a.js", "lib/a.test.js", "lib/util/b.d.ts", "lib/util/b.js", "package.json", "readme.md~"},
+		},
+		{
+			"the .gitignore is the fallback of .npmignore",
+			`{}`, map[string]string{".gitignore": "docs/\n*.d.ts\n"},
+			[]string{"LICENSE", "README.md", "bin/cli.js", "index.js", "lib/a.js", "lib/a.test.js", "lib/util/b.js", "package.json", "readme.md~"},
+		},
+		{
+			".npmignore has the precedence",
+			`{}`, map[string]string{".gitignore": "docs/\n", ".npmignore": "*.test.js\nREADME.md\n", "lib/util/.gitignore": "*.js\n"},
+			[]string{"LICENSE", "README.md", "bin/cli.js", "docs/guide.md", "index.js", "lib/a.js", "lib/util/b.d.ts", "package.json", "readme.md~"},
+		},
+		{
+			"the defaults can be overridden",
+			`{}`, map[string]string{".npmignore": "*\n!/package-lock.json\n"},
+			[]string{"LICENSE", "README.md", "package-lock.json", "package.json"},
+		},
+		{
+			"files",
+			`{"files": ["lib/", "!lib/*.test.js", "docs/*.md"], "main": "index.js", "bin": {"cli": "./bin/cli.js"}}`,
+			map[string]string{".npmignore": "lib/\n", "lib/util/.npmignore": "*.d.ts\n"},
+			[]string{"LICENSE", "README.md", "bin/cli.js", "docs/guide.md", "index.js", "lib/a.js", "lib/util/b.js", "package.json"},
+		},
+		{
+			"empty files",
+			`{"files": [], "bin": "bin/cli.js"}`, nil,
+			[]string{"LICENSE", "README.md", "bin/cli.js", "package.json"},
+		},
+	}
+	for _, testCase := range cases {
+		files, err := NpmPackFiles(npmPackage(testCase.packageJSON, testCase.files), "pkg")
+		assert.Nil(t, err, testCase.name)
+		assert.Equal(t, files, testCase.expected, testCase.name)
+	}
+}
+
+func TestNpmPackFilesErrors(t *testing.T) {
+	_, err := NpmPackFiles(fstest.MapFS{"pkg/index.js": {}}, "pkg")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	_, err = NpmPackFiles(fstest.MapFS{"pkg/package.json": {Data: []byte("{")}}, "pkg")
+	assert.Equal(t, err.Error(), "pkg/package.json: unexpected end of JSON input")
+}
+
+func TestIsNpmAlwaysIncluded(t *testing.T) {
+	for _, name := range []string{"package.json", "README", "readme.md", "License.txt", "LICENCE", "copying"} {
+		assert.True(t, isNpmAlwaysIncluded(name), name)
+	}
+	for _, name := range []string{"README.md~", "readme-foo", "index.js", "package.json5"} {
+		assert.False(t, isNpmAlwaysIncluded(name), name)
+	}
+}
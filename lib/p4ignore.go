@@ -0,0 +1,217 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+/**
+ * Parses the content of a Perforce `.p4ignore` file into gitignore patterns. The syntax is like gitignore, with these
+ * differences:
+ * - A rule without a leading `/` matches at any level, even if it has a `/` in the middle (e.g. `doc/*.md` becomes
+ *   `**\/doc/*.md`). A leading `/` anchors the rule to the directory of the `.p4ignore`.
+ * - `**` matches any string including `/` wherever it is (e.g. `test/**.txt` becomes `test/**\/*.txt`), and it has no
+ *   special meaning as a whole segment.
+ * - There are no escapes, `?` or character classes, so `\`, `?` and `[` are literal.
+ * - The trailing whitespace is removed.
+ * The `!` of the negations, the trailing `/` of the directories and the `#` of the comments are the same.
+ *
+ * @param {string} p4IgnoreContent The content of the p4ignore file
+ * @returns {([]Pattern, error)} The patterns in the order of the file, and an error that joins a `*ParseError` for each
+ *   rule that needs too many gitignore patterns, which is skipped
+ */
+func ParseP4Ignore(p4IgnoreContent string) ([]Pattern, error) {
+	patterns := []Pattern{}
+	errs := []error{}
+	lines := strings.Split(strings.TrimPrefix(p4IgnoreContent, byteOrderMark), "\n")
+	for iLine := range lines {
+		rule := strings.TrimRightFunc(lines[iLine], unicode.IsSpace)
+		if rule == "" || strings.HasPrefix(rule, "#") {
+			continue
+		}
+		negated := strings.HasPrefix(rule, "!")
+		text := strings.TrimPrefix(rule, "!")
+		dirOnly := strings.HasSuffix(text, "/")
+		text = strings.TrimSuffix(text, "/")
+		if text == "" || text == "/" {
+			continue
+		}
+
+		var builder strings.Builder
+		if !strings.HasPrefix(text, "/") {
+			// any level
+			builder.WriteString(hgAnyString + "/")
+		}
+		for i := 0; i < len(text); i++ {
+			switch text[i] {
+			case '*':
+				if charAt(text, i+1) != '*' {
+					builder.WriteByte('*')
+					continue
+				}
+				for charAt(text, i+1) == '*' {
+					i++
+				}
+				builder.WriteString(hgAnyString)
+			case '\\', '?', '[':
+				builder.WriteByte('\\')
+				builder.WriteByte(text[i])
+			default:
+				builder.WriteByte(text[i])
+			}
+		}
+		rulePatterns, err := hgPatterns([]string{builder.String()}, dirOnly)
+		if err != nil {
+			errs = append(errs, &ParseError{Line: iLine + 1, Pattern: rule, Err: err})
+			continue
+		}
+		for _, pattern := range rulePatterns {
+			pattern.Negated = negated
+			pattern.Line = iLine + 1
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns, errors.Join(errs...)
+}
+
+/**
+ * Converts gitignore patterns to a Perforce `.p4ignore` file that ignores the same paths (see `ParseP4Ignore` for the
+ * differences of the syntax). The anchored patterns get a leading `/`, a `**` segment in the middle becomes two rules
+ * (e.g. `a/**\/b` becomes `/a/b` and `/a/**\/b`), and a trailing `/**` becomes `/*`, which ignores the same files.
+ *
+ * @param {[]Pattern} patterns The patterns (e.g. from `ParseGitIgnore`). Their `Base` is relative to the directory of
+ *   the p4ignore file.
+ * @returns {(string, error)} The content of the p4ignore file, and an error that joins a `*ParseError` for each pattern
+ *   that is malformed or that Perforce cannot express (`?`, a character class, an escaped `*`, a trailing space, or a
+ *   leading `!` or `#` that is not a negation or a comment), which is skipped
+ */
+func RenderP4Ignore(patterns []Pattern) (string, error) {
+	var builder strings.Builder
+	errs := []error{}
+	for _, pattern := range patterns {
+		lines, err := p4IgnoreLines(pattern)
+		if err != nil {
+			errs = append(errs, &ParseError{Source: pattern.Source, Line: pattern.Line, Pattern: pattern.String(), Err: err})
+			continue
+		}
+		for _, line := range lines {
+			builder.WriteString(line)
+			builder.WriteByte('\n')
+		}
+	}
+	return builder.String(), errors.Join(errs...)
+}
+
+/** Converts a pattern to the rules of a `.p4ignore` */
+func p4IgnoreLines(pattern Pattern) ([]string, error) {
+	if err := CheckGitIgnorePattern(pattern.Text); err != nil {
+		return nil, err
+	}
+	text := strings.TrimPrefix(pattern.Text, "/")
+	switch {
+	case !pattern.Anchored && pattern.Base != "":
+		text = gitIgnoreEscape(pattern.Base) + "/**/" + text
+	case pattern.Base != "":
+		text = gitIgnoreEscape(pattern.Base) + "/" + text
+	}
+	segments := splitGlobSegments(CanonicalGlob(text))
+	anchored := pattern.Anchored || pattern.Base != ""
+	if anchored && segments[0] == "**" && len(segments) > 1 {
+		// `**/a/b` matches at any level like the rules of Perforce without a leading `/`
+		segments, anchored = segments[1:], false
+	}
+	if segments[len(segments)-1] == "**" {
+		// Perforce ignores the same files with `a/*`, as the matched directories are not read
+		segments[len(segments)-1] = "*"
+	}
+	if last := segments[len(segments)-1]; strings.TrimRightFunc(last, unicode.IsSpace) != last {
+		return nil, fmt.Errorf("%w: Perforce removes the trailing whitespace", ErrUnsupportedPattern)
+	}
+
+	alternatives := []string{""}
+	for iSegment, segment := range segments {
+		separator := "/"
+		if iSegment == 0 {
+			separator = ""
+		}
+		if segment == "**" {
+			// `a/**/b` also matches `a/b`, but the `**` of Perforce needs the two `/` around it
+			extended := []string{}
+			for _, alternative := range alternatives {
+				extended = append(extended, alternative, alternative+separator+"**")
+			}
+			alternatives = extended
+			continue
+		}
+		converted, err := p4Segment(segment, iSegment == 0 && !anchored)
+		if err != nil {
+			return nil, err
+		}
+		for iAlternative := range alternatives {
+			if alternatives[iAlternative] != "" {
+				alternatives[iAlternative] += separator
+			}
+			alternatives[iAlternative] += converted
+		}
+		if len(alternatives) > maxHgAlternatives {
+			return nil, fmt.Errorf("%w: more than %d alternatives", ErrUnsupportedPattern, maxHgAlternatives)
+		}
+	}
+
+	lines := []string{}
+	for _, alternative := range unique(alternatives) {
+		if anchored {
+			alternative = "/" + alternative
+		}
+		if pattern.Negated {
+			alternative = "!" + alternative
+		}
+		if pattern.DirOnly {
+			alternative += "/"
+		}
+		lines = append(lines, alternative)
+	}
+	return lines, nil
+}
+
+/**
+ * Converts a segment of a gitignore pattern (not `**`) to Perforce
+ *
+ * @param {string} segment The segment
+ * @param {bool} first Whether it starts the rule, where `!` and `#` are special
+ * @returns {(string, error)} The segment, or the error if Perforce cannot express it
+ */
+func p4Segment(segment string, first bool) (string, error) {
+	var builder strings.Builder
+	for i := 0; i < len(segment); i++ {
+		ch := segment[i]
+		switch ch {
+		case '\\':
+			i++
+			ch = segment[i]
+			if ch == '*' {
+				return "", fmt.Errorf("%w: Perforce cannot escape *", ErrUnsupportedPattern)
+			}
+			builder.WriteByte(ch)
+		case '*':
+			for charAt(segment, i+1) == '*' {
+				i++
+			}
+			builder.WriteByte('*')
+		case '?', '[':
+			return "", fmt.Errorf("%w: Perforce has no %c wildcard", ErrUnsupportedPattern, ch)
+		default:
+			builder.WriteByte(ch)
+		}
+	}
+	converted := builder.String()
+	switch {
+	case converted == "":
+		return "", ErrEmptyPattern
+	case first && (converted[0] == '!' || converted[0] == '#'):
+		return "", fmt.Errorf("%w: Perforce reads a leading %c as a negation or a comment", ErrUnsupportedPattern, converted[0])
+	}
+	return converted, nil
+}
@@ -0,0 +1,111 @@
+package lib
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseP4Ignore(t *testing.T) {
+	cases := []struct {
+		p4Ignore string
+		expected []string
+	}{
+		{"*.o\nbin/\n!keep.o\n# comment\n", []string{"*.o", "bin/", "!keep.o"}},
+		// the rules with a `/` in the middle match at any level
+		{"doc/*.md\n/build.properties\n", []string{"**/doc/*.md", "/build.properties"}},
+		// `**` matches any string wherever it is
+		{"test/**.txt\n/a**b\n/out/**\n**/x\n", []string{"**/test/**/*.txt", "/a*b", "a*/**/*b", "out/**", "x"}},
+		// there are no escapes, `?` or classes
+		{"a?b\n[x]\n/c\\d\n", []string{"a\\?b", "\\[x]", "/c\\\\d"}},
+		{"trailing  \r\n", []string{"trailing"}},
+	}
+	for _, testCase := range cases {
+		patterns, err := ParseP4Ignore(testCase.p4Ignore)
+		assert.Nil(t, err, testCase.p4Ignore)
+		assert.Equal(t, patternStrings(patterns), testCase.expected, testCase.p4Ignore)
+	}
+}
+
+func TestRenderP4Ignore(t *testing.T) {
+	cases := []struct {
+		gitIgnore string
+		expected  string
+	}{
+		{"*.o\nbin/\n!keep.o\n", "*.o\nbin/\n!keep.o\n"},
+		// the gitignore patterns with a `/` in the middle are anchored
+		{"doc/*.md\n/dist\n**/logs/*.log\n", "/doc/*.md\n/dist\nlogs/*.log\n"},
+		{"a/**/b\nout/**\n", "/a/b\n/a/**/b\n/out/*\n"},
+		{"a\\ b\n\\[x]\n", "a b\n[x]\n"},
+	}
+	for _, testCase := range cases {
+		p4Ignore, err := RenderP4Ignore(ParseGitIgnore(testCase.gitIgnore))
+		assert.Nil(t, err, testCase.gitIgnore)
+		assert.Equal(t, p4Ignore, testCase.expected, testCase.gitIgnore)
+	}
+
+	p4Ignore, err := RenderP4Ignore(ParseGitIgnore("*.o\nx/*\n", "sub"))
+	assert.Nil(t, err)
+	assert.Equal(t, p4Ignore, "/sub/*.o\n/sub/**/*.o\n/sub/x/*\n")
+}
+
+func TestRenderP4IgnoreErrors(t *testing.T) {
+	p4Ignore, err := RenderP4Ignore(ParseGitIgnore("ok\na?\n[ab]\n\\*\ntrail\\ \n\\#x\n\\!x\nfoo[\n"))
+	assert.Equal(t, p4Ignore, "ok\n")
+	assert.True(t, errors.Is(err, ErrUnsupportedPattern))
+	assert.True(t, errors.Is(err, ErrUnterminatedCharacterClass))
+	assert.Equal(t, strings.Split(err.Error(), "\n"), []string{
+		`line 2: "a?": unsupported pattern: Perforce has no ? wildcard`,
+		`line 3: "[ab]": unsupported pattern: Perforce has no [ wildcard`,
+		`line 4: "\\*": unsupported pattern: Perforce cannot escape *`,
+		`line 5: "trail\\ ": unsupported pattern: Perforce removes the trailing whitespace`,
+		`line 6: "\\#x": unsupported pattern: Perforce reads a leading # as a negation or a comment`,
+		`line 7: "\\!x": unsupported pattern: Perforce reads a leading ! as a negation or a comment`,
+		`line 8: "foo[": unterminated character class`,
+	})
+}
+
+/** The same rule ignores different paths in the two formats */
+func TestP4IgnoreDivergence(t *testing.T) {
+	cases := []struct {
+		rule     string
+		name     string
+		git      bool
+		perforce bool
+	}{
+		// a `/` in the middle anchors the gitignore patterns only
+		{"doc/a.md", "doc/a.md", true, true},
+		{"doc/a.md", "sub/doc/a.md", false, true},
+		// `**` inside a name is `*` for git, and crosses the directories for Perforce
+		{"/a**b", "axb", true, true},
+		{"/a**b", "a/x/b", false, true},
+		// `?` is a wildcard for git, and a literal for Perforce
+		{"a?", "ab", true, false},
+		{"a?", "a?", true, true},
+	}
+	for _, testCase := range cases {
+		p4Patterns, err := ParseP4Ignore(testCase.rule)
+		assert.Nil(t, err, testCase.rule)
+		assert.Equal(t, NewMatcher(ParseGitIgnore(testCase.rule)).Ignored(testCase.name, false), testCase.git, testCase.rule+" "+testCase.name)
+		assert.Equal(t, NewMatcher(p4Patterns).Ignored(testCase.name, false), testCase.perforce, testCase.rule+" "+testCase.name)
+	}
+}
+
+/** The rendered p4ignore is parsed back to patterns that ignore the same paths */
+func TestRenderP4IgnoreRoundTrip(t *testing.T) {
+	gitIgnore := "*.o\n/dist\ndoc/*.md\nsrc/**/gen\nout/**\n!keep.o\nbuild/\n"
+	paths := []string{"a.o", "x/a.o", "keep.o", "dist", "x/dist", "doc/a.md", "x/doc/a.md", "src/gen", "src/a/b/gen",
+		"gen", "out/a", "out/a/b", "build/a", "x/build/a", "build"}
+	p4Ignore, err := RenderP4Ignore(ParseGitIgnore(gitIgnore))
+	assert.Nil(t, err)
+	patterns, err := ParseP4Ignore(p4Ignore)
+	assert.Nil(t, err)
+
+	matcher := NewMatcher(ParseGitIgnore(gitIgnore))
+	roundTrip := NewMatcher(patterns)
+	for _, name := range paths {
+		assert.Equal(t, roundTrip.Ignored(name, false), matcher.Ignored(name, false), name)
+	}
+}
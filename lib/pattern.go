@@ -0,0 +1,201 @@
+package lib
+
+import (
+	"path"
+	"strings"
+)
+
+/** A parsed gitignore pattern */
+type Pattern struct {
+	/** The pattern without the leading `!` and the trailing `/`. A leading `/` is kept. */
+	Text string
+	/** The pattern started with `!` and re-includes the matched paths */
+	Negated bool
+	/** The pattern ended with `/` and only matches directories */
+	DirOnly bool
+	/** The pattern has a `/` at the beginning or in the middle, so it is relative to `Base` instead of matching at any level */
+	Anchored bool
+	/** The posix directory (relative to the root of the matching) of the ignore file. Empty for the root. */
+	Base string
+	/** The ignore file the pattern was read from (if any) */
+	Source string
+	/** The 1-based line number of the pattern in `Source` */
+	Line int
+	/** The include directive that read `Source`, or nil if the file was not included (see `ParseGitIgnoreWithIncludes`) */
+	IncludedBy *Include
+}
+
+/**
+ * Parses one gitignore pattern
+ *
+ * NOTE: unlike `TrimWhiteSpace`, this follows git and only removes the unescaped trailing spaces. Leading whitespace is
+ * part of the pattern.
+ *
+ * @param {string} line One line of a gitignore file
+ * @returns {(Pattern, bool)} The pattern, and false if the line is empty or a comment
+ */
+func ParseGitIgnorePattern(line string) (Pattern, bool) {
+	pattern := Pattern{}
+	if line == "" || line[0] == '#' {
+		return pattern, false
+	}
+	entry := trimTrailingSpaces(line)
+	if strings.HasPrefix(entry, "!") {
+		pattern.Negated = true
+		entry = entry[1:]
+	}
+	if strings.HasSuffix(entry, "/") {
+		pattern.DirOnly = true
+		entry = entry[:len(entry)-1]
+	}
+	if entry == "" {
+		// git keeps such patterns, but they can never match
+		return pattern, false
+	}
+	pattern.Anchored = strings.Contains(entry, "/")
+	pattern.Text = entry
+	return pattern, true
+}
+
+/**
+ * Parses the content of a `.gitignore` file
+ *
+ * @param {string} gitIgnoreContent The content of the gitignore file
+ * @param {Optional string} gitIgnoreDirectory The posix directory of the gitignore relative to the root of the matching
+ * @returns {[]Pattern} The patterns in the order of the file
+ */
+func ParseGitIgnore(
+	gitIgnoreContent string,
+	gitIgnoreDirectory ...string,
+) []Pattern {
+	base := ""
+	if len(gitIgnoreDirectory) == 1 {
+		base = cleanBase(gitIgnoreDirectory[0])
+	}
+
+	patterns := []Pattern{}
+	lines := strings.Split(strings.TrimPrefix(gitIgnoreContent, byteOrderMark), "\n")
+	for iLine := range lines {
+		pattern, ok := ParseGitIgnorePattern(trimCarriageReturn(lines[iLine]))
+		if !ok {
+			continue
+		}
+		pattern.Base = base
+		pattern.Line = iLine + 1
+		patterns = append(patterns, pattern)
+	}
+	return patterns
+}
+
+/** The UTF-8 byte order mark. Like git, it is skipped at the beginning of the ignore files. */
+const byteOrderMark = "\uFEFF"
+
+/** Removes the `\r` of a CRLF line ending */
+func trimCarriageReturn(line string) string {
+	return strings.TrimSuffix(line, "\r")
+}
+
+/** Converts a directory to the form used by `Pattern.Base` */
+func cleanBase(directory string) string {
+	base := path.Clean(PosixifyPath(directory))
+	if base == "." || base == "/" {
+		return ""
+	}
+	return strings.TrimPrefix(base, "./")
+}
+
+/** Like git, removes the trailing spaces that are not escaped with a backslash */
+func trimTrailingSpaces(str string) string {
+	lastSpace := -1
+	for i := 0; i < len(str); i++ {
+		switch str[i] {
+		case ' ':
+			if lastSpace == -1 {
+				lastSpace = i
+			}
+		case '\\':
+			i++
+			if i == len(str) {
+				return str
+			}
+			lastSpace = -1
+		default:
+			lastSpace = -1
+		}
+	}
+	if lastSpace != -1 {
+		return str[:lastSpace]
+	}
+	return str
+}
+
+/** Converts the pattern back to its gitignore form */
+func (pattern Pattern) String() string {
+	str := pattern.Text
+	if pattern.Negated {
+		str = "!" + str
+	}
+	if pattern.DirOnly {
+		str += "/"
+	}
+	return str
+}
+
+/**
+ * Does the pattern match the given path? Parent directories are not considered (see `Matcher`).
+ *
+ * @param {string} name The posix path relative to the root of the matching
+ * @param {bool} isDir Whether the path is a directory
+ * @returns {bool}
+ */
+func (pattern *Pattern) Match(name string, isDir bool) bool {
+	return pattern.match(name, isDir, 0)
+}
+
+/**
+ * Like `Match`, but compares the ASCII letters case-insensitively like git does with `core.ignoreCase`
+ *
+ * @param {string} name The posix path relative to the root of the matching
+ * @param {bool} isDir Whether the path is a directory
+ * @returns {bool}
+ */
+func (pattern *Pattern) MatchIgnoreCase(name string, isDir bool) bool {
+	return pattern.match(name, isDir, WildmatchCaseFold)
+}
+
+func (pattern *Pattern) match(name string, isDir bool, flags WildmatchFlags) bool {
+	if pattern.DirOnly && !isDir {
+		return false
+	}
+
+	// the pattern only applies to the paths below its gitignore directory
+	relative := name
+	if pattern.Base != "" {
+		if len(name) <= len(pattern.Base)+1 || name[len(pattern.Base)] != '/' {
+			return false
+		}
+		if base := name[:len(pattern.Base)]; base != pattern.Base && !(flags&WildmatchCaseFold != 0 && equalFoldASCII(base, pattern.Base)) {
+			return false
+		}
+		relative = name[len(pattern.Base)+1:]
+	}
+
+	if !pattern.Anchored {
+		// match the basename at any level
+		return Wildmatch(pattern.Text, path.Base(relative), flags)
+	}
+	return Wildmatch(strings.TrimPrefix(pattern.Text, "/"), relative, flags|WildmatchPathname)
+}
+
+/** Are the strings equal if the ASCII letters are compared case-insensitively? */
+func equalFoldASCII(a string, b string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := 0; i < len(a); i++ {
+		if toLower(a[i]) != toLower(b[i]) {
+			return false
+		}
+	}
+	return true
+}
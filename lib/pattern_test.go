@@ -0,0 +1,108 @@
+package lib
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseGitIgnorePattern(t *testing.T) {
+	pattern, ok := ParseGitIgnorePattern("!/abs_dir/ ")
+	assert.Equal(t, ok, true)
+	assert.Equal(t, pattern, Pattern{Text: "/abs_dir", Negated: true, DirOnly: true, Anchored: true})
+	assert.Equal(t, pattern.String(), "!/abs_dir/")
+
+	pattern, ok = ParseGitIgnorePattern("dir/")
+	assert.Equal(t, ok, true)
+	assert.Equal(t, pattern, Pattern{Text: "dir", DirOnly: true})
+
+	pattern, ok = ParseGitIgnorePattern("  leading")
+	assert.Equal(t, ok, true)
+	assert.Equal(t, pattern.Text, "  leading")
+
+	pattern, ok = ParseGitIgnorePattern("escaped\\  ")
+	assert.Equal(t, ok, true)
+	assert.Equal(t, pattern.Text, "escaped\\ ")
+
+	pattern, ok = ParseGitIgnorePattern("\\#hash")
+	assert.Equal(t, ok, true)
+	assert.Equal(t, pattern.Text, "\\#hash")
+
+	_, ok = ParseGitIgnorePattern("# comment")
+	assert.Equal(t, ok, false)
+	_, ok = ParseGitIgnorePattern("")
+	assert.Equal(t, ok, false)
+	_, ok = ParseGitIgnorePattern("   ")
+	assert.Equal(t, ok, false)
+	_, ok = ParseGitIgnorePattern("!")
+	assert.Equal(t, ok, false)
+}
+
+func TestParseGitIgnore(t *testing.T) {
+	patterns := ParseGitIgnore("# comment\n*.log\n\n!keep.log\n", "./sub/")
+	assert.Equal(t, patterns, []Pattern{
+		{Text: "*.log", Base: "sub", Line: 2},
+		{Text: "keep.log", Negated: true, Base: "sub", Line: 4},
+	})
+
+	// the byte order mark and the CRLF line endings are not part of the patterns
+	patterns = ParseGitIgnore("\uFEFF#comment\r\n build/ \r\n\r\n")
+	assert.Equal(t, patterns, []Pattern{{Text: " build", DirOnly: true, Line: 2}})
+}
+
+func TestPatternMatch(t *testing.T) {
+	match := func(line string, name string, isDir bool) bool {
+		pattern, _ := ParseGitIgnorePattern(line)
+		return pattern.Match(name, isDir)
+	}
+	assert.Equal(t, match("*.js", "a/b/c.js", false), true)
+	assert.Equal(t, match("*.js", "a/b/c.jsx", false), false)
+	assert.Equal(t, match("dir/", "a/dir", true), true)
+	assert.Equal(t, match("dir/", "a/dir", false), false)
+	assert.Equal(t, match("/dir", "dir", false), true)
+	assert.Equal(t, match("/dir", "a/dir", false), false)
+	assert.Equal(t, match("a/*.js", "a/c.js", false), true)
+	assert.Equal(t, match("a/*.js", "a/b/c.js", false), false)
+	assert.Equal(t, match("a/**/c.js", "a/b/c.js", false), true)
+	assert.Equal(t, match("**/b", "a/b", true), true)
+
+	pattern := Pattern{Text: "c.js", Base: "a"}
+	assert.Equal(t, pattern.Match("a/b/c.js", false), true)
+	assert.Equal(t, pattern.Match("c.js", false), false)
+	assert.Equal(t, pattern.Match("ab/c.js", false), false)
+}
+
+func TestPatternMatchIgnoreCase(t *testing.T) {
+	pattern := Pattern{Text: "/Src/*.JS", Anchored: true, Base: "Sub"}
+	assert.Equal(t, pattern.Match("sub/src/a.js", false), false)
+	assert.Equal(t, pattern.MatchIgnoreCase("sub/src/a.js", false), true)
+	assert.Equal(t, pattern.MatchIgnoreCase("SUB/SRC/A.JS", false), true)
+	assert.Equal(t, pattern.MatchIgnoreCase("subx/src/a.js", false), false)
+
+	// like git, the capital letters of the character classes only match themselves
+	pattern, _ = ParseGitIgnorePattern("[A]bc")
+	assert.Equal(t, pattern.MatchIgnoreCase("abc", false), false)
+	pattern, _ = ParseGitIgnorePattern("[Q-T]x")
+	assert.Equal(t, pattern.MatchIgnoreCase("sX", false), true)
+}
+
+func FuzzParseGitIgnore(f *testing.F) {
+	for _, content := range []string{"", "\n", "#", "!", "*.log\n!keep.log\n", "dir/ \n/abs\\ \n", "a//\n!!b\n\\#c\n", "a \t\r\n"} {
+		f.Add(content)
+	}
+	f.Fuzz(func(t *testing.T, content string) {
+		lineCount := strings.Count(content, "\n") + 1
+		for _, pattern := range ParseGitIgnore(content, "sub") {
+			if pattern.Text == "" || pattern.Line < 1 || pattern.Line > lineCount {
+				t.Fatalf("invalid pattern %+v", pattern)
+			}
+			// the string form parses back to the same pattern
+			reparsed, ok := ParseGitIgnorePattern(pattern.String())
+			reparsed.Base, reparsed.Line = pattern.Base, pattern.Line
+			if !ok || reparsed != pattern {
+				t.Fatalf("%q parses to %+v instead of %+v", pattern.String(), reparsed, pattern)
+			}
+		}
+	})
+}
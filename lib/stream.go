@@ -0,0 +1,166 @@
+package lib
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+/**
+ * Reads the patterns of a gitignore one at a time, so the memory does not grow with the size of the file. The patterns
+ * are the same as the ones of `ParseGitIgnore`.
+ */
+type Parser struct {
+	/** The ignore file that is read (if known). It is copied to the patterns. */
+	Source string
+
+	reader *bufio.Reader
+	base   string
+	line   int
+	err    error
+}
+
+/**
+ * Creates a parser that reads the given gitignore
+ *
+ * @param {io.Reader} reader The content of the gitignore file
+ * @param {Optional string} gitIgnoreDirectory The posix directory of the gitignore relative to the root of the matching
+ * @returns {*Parser}
+ */
+func NewParser(reader io.Reader, gitIgnoreDirectory ...string) *Parser {
+	base := ""
+	if len(gitIgnoreDirectory) == 1 {
+		base = cleanBase(gitIgnoreDirectory[0])
+	}
+	return &Parser{reader: bufio.NewReader(reader), base: base}
+}
+
+/**
+ * Reads the next pattern. The empty lines and the comments are skipped.
+ *
+ * @returns {(Pattern, error)} The pattern, or `io.EOF` after the last one, or the error of the reader
+ */
+func (parser *Parser) Next() (Pattern, error) {
+	for parser.err == nil {
+		line, err := parser.reader.ReadString('\n')
+		if err != nil {
+			parser.err = err
+			if err != io.EOF || line == "" {
+				break
+			}
+		}
+		if parser.line == 0 {
+			line = strings.TrimPrefix(line, byteOrderMark)
+		}
+		parser.line++
+		pattern, ok := ParseGitIgnorePattern(trimCarriageReturn(strings.TrimSuffix(line, "\n")))
+		if !ok {
+			continue
+		}
+		pattern.Base = parser.base
+		pattern.Source = parser.Source
+		pattern.Line = parser.line
+		return pattern, nil
+	}
+	return Pattern{}, parser.err
+}
+
+/** Writes the globs of patterns to an `io.Writer` as they are parsed */
+type Renderer struct {
+	writer    io.Writer
+	options   GlobifyOptions
+	separator string
+	buffer    []byte
+	/** The escaped base directory, or the error of resolving it */
+	prefix    string
+	prefixErr error
+}
+
+/**
+ * Creates a renderer that writes to the given writer
+ *
+ * @param {io.Writer} writer The output
+ * @param {GlobifyOptions} options The options of the conversion
+ * @param {Optional string} separator ["\n"] Written after each glob
+ * @returns {*Renderer}
+ */
+func NewRenderer(writer io.Writer, options GlobifyOptions, separator ...string) *Renderer {
+	renderer := &Renderer{writer: writer, options: options, separator: "\n"}
+	if len(separator) == 1 {
+		renderer.separator = separator[0]
+	}
+	renderer.prefix, renderer.prefixErr = options.basePrefix()
+	return renderer
+}
+
+/**
+ * Writes the globs of a pattern. The base directory of the pattern (e.g. from `NewParser(reader, "sub")`) is below
+ * `GlobifyOptions.Directory`, like the directory of `GlobifyGitIgnoreEntry`.
+ *
+ * NOTE: unlike `GlobifyGitIgnore`, the `/**` glob of an entry directly follows it instead of being moved to the end of
+ * the list, and the duplicates are not removed.
+ *
+ * @param {Pattern} pattern The pattern (e.g. from `Parser.Next`)
+ * @returns {error} A `*ParseError` if the pattern is malformed (nothing is written), a `*PathProbeError` if the type
+ *   of an anchored pattern could not be probed (the globs are still written), the error of resolving the base
+ *   directory (see `GlobifyOptions.Base`), or the error of the writer
+ */
+func (renderer *Renderer) Render(pattern Pattern) error {
+	if renderer.prefixErr != nil {
+		return renderer.prefixErr
+	}
+	entry := pattern.String()
+	if err := checkEntry(entry); err != nil {
+		err.Source = pattern.Source
+		err.Line = pattern.Line
+		return err
+	}
+	options, prefix := renderer.options, renderer.prefix
+	if pattern.Base != "" {
+		// the base is a literal posix path
+		options.Directory = filepath.Join(options.Directory, filepath.FromSlash(pattern.Base))
+		prefix += options.Dialect.Escape(options.Normalization.Normalize(pattern.Base)) + "/"
+	}
+	globs, probeErr := globifyGitIgnoreEntry(entry, options, prefix)
+	for _, glob := range globs {
+		renderer.buffer = append(append(renderer.buffer[:0], glob...), renderer.separator...)
+		if _, err := renderer.writer.Write(renderer.buffer); err != nil {
+			return err
+		}
+	}
+	return probeErr
+}
+
+/**
+ * Converts a gitignore to globs while it is read. The memory does not grow with the size of the gitignore. The lines
+ * are parsed exactly like git (see `GlobifyOptions.Strict`), and the globs are in the order of `Renderer.Render`.
+ *
+ * @param {io.Reader} reader The content of the gitignore file
+ * @param {io.Writer} writer The output. Each glob is followed by a new line.
+ * @param {GlobifyOptions} options The options of the conversion
+ * @returns {error} The error of the reader or the writer, or an error that joins a `*ParseError` for each malformed
+ *   entry (which is skipped) and a `*PathProbeError` for each entry whose type could not be probed
+ */
+func GlobifyGitIgnoreStream(reader io.Reader, writer io.Writer, options GlobifyOptions) error {
+	parser := NewParser(reader)
+	renderer := NewRenderer(writer, options)
+	errs := []error{}
+	for {
+		pattern, err := parser.Next()
+		if err == io.EOF {
+			return errors.Join(errs...)
+		} else if err != nil {
+			return err
+		}
+		if err := renderer.Render(pattern); err != nil {
+			var parseError *ParseError
+			var probeError *PathProbeError
+			if !errors.As(err, &parseError) && !errors.As(err, &probeError) {
+				return err
+			}
+			errs = append(errs, err)
+		}
+	}
+}
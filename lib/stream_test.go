@@ -0,0 +1,128 @@
+package lib
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/** Reads all the patterns of a parser */
+func parseAll(parser *Parser) ([]Pattern, error) {
+	patterns := []Pattern{}
+	for {
+		pattern, err := parser.Next()
+		if err != nil {
+			return patterns, err
+		}
+		patterns = append(patterns, pattern)
+	}
+}
+
+func TestParser(t *testing.T) {
+	content := "\uFEFF# comment\n\n*.log\n!keep.log\ndir/ \n/abs\\ \r\nlast"
+	parser := NewParser(iotest.OneByteReader(strings.NewReader(content)), "./sub/")
+	parser.Source = "sub/.gitignore"
+	patterns, err := parseAll(parser)
+	assert.Equal(t, err, io.EOF)
+
+	expected := ParseGitIgnore(content, "./sub/")
+	for iPattern := range expected {
+		expected[iPattern].Source = "sub/.gitignore"
+	}
+	assert.Equal(t, patterns, expected)
+	assert.Equal(t, patterns[len(patterns)-1].Line, 7)
+
+	// the end is reported again
+	_, err = parser.Next()
+	assert.Equal(t, err, io.EOF)
+}
+
+func TestParserError(t *testing.T) {
+	reader := io.MultiReader(strings.NewReader("a\nb"), iotest.ErrReader(iotest.ErrTimeout))
+	patterns, err := parseAll(NewParser(reader))
+	assert.Equal(t, err, iotest.ErrTimeout)
+	// the incomplete last line is not parsed
+	assert.Equal(t, len(patterns), 1)
+}
+
+func TestRenderer(t *testing.T) {
+	var output bytes.Buffer
+	renderer := NewRenderer(&output, GlobifyOptions{Directory: "root", Dialect: DialectDoublestar}, "\x00")
+	patterns := ParseGitIgnore("*.{js,ts}\n!keep.js\n/dir/\nfoo[\n")
+	for _, pattern := range patterns[:3] {
+		assert.Nil(t, renderer.Render(pattern))
+	}
+	assert.Equal(t, output.String(), "!root/**/*.\\{js,ts\\}\x00!root/**/*.\\{js,ts\\}/*/**\x00"+
+		"root/**/keep.js\x00root/**/keep.js/*/**\x00!root/dir/*/**\x00")
+
+	err := renderer.Render(patterns[3])
+	assert.EqualError(t, err, "line 4, column 4: \"foo[\": unterminated character class")
+	assert.Equal(t, strings.Count(output.String(), "\x00"), 5)
+
+	// the base directory of the patterns is below the directory
+	output.Reset()
+	renderer = NewRenderer(&output, GlobifyOptions{Directory: "root"})
+	parser := NewParser(strings.NewReader("*.log\n/dist/\n"), "sub/[x]")
+	for pattern, err := parser.Next(); err == nil; pattern, err = parser.Next() {
+		assert.Nil(t, renderer.Render(pattern))
+	}
+	assert.Equal(t, output.String(), "!root/sub/\\[x\\]/**/*.log\n!root/sub/\\[x\\]/**/*.log/**\n!root/sub/\\[x\\]/dist/**\n")
+	assert.Equal(t, GlobifyGitIgnoreEntry("/dist/", "root/sub/[x]"), []string{"!root/sub/\\[x\\]/dist/**"})
+}
+
+func TestGlobifyGitIgnoreStream(t *testing.T) {
+	var output bytes.Buffer
+	err := GlobifyGitIgnoreStream(strings.NewReader("*.log\n!\nfoo[\n/dir/\n"), &output, GlobifyOptions{})
+	assert.Equal(t, output.String(), "!**/*.log\n!**/*.log/**\n!dir/**\n")
+	// `!` is not a pattern for git
+	assert.EqualError(t, err, "line 3, column 4: \"foo[\": unterminated character class")
+
+	// the errors of the writer stop the conversion
+	err = GlobifyGitIgnoreStream(strings.NewReader("a\nb\n"), failingWriter{}, GlobifyOptions{})
+	assert.Equal(t, err, errWrite)
+}
+
+var errWrite = errors.New("write error")
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errWrite
+}
+
+func BenchmarkGlobifyGitIgnoreStream(b *testing.B) {
+	content := generatedGitIgnore(100000)
+	b.SetBytes(int64(len(content)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := GlobifyGitIgnoreStream(strings.NewReader(content), io.Discard, GlobifyOptions{Directory: "root"}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func FuzzParser(f *testing.F) {
+	for _, content := range []string{"", "\n", "#", "!", "*.log\n!keep.log\n", "dir/ \n/abs\\ \n", "a\r\nb", "a\n\n", "\uFEFF#a\r\n\uFEFFb"} {
+		f.Add(content)
+	}
+	f.Fuzz(func(t *testing.T, content string) {
+		patterns, err := parseAll(NewParser(iotest.HalfReader(strings.NewReader(content)), "sub"))
+		if err != io.EOF {
+			t.Fatal(err)
+		}
+		expected := ParseGitIgnore(content, "sub")
+		if len(patterns) != len(expected) {
+			t.Fatalf("%d patterns instead of %d", len(patterns), len(expected))
+		}
+		for iPattern := range patterns {
+			if patterns[iPattern] != expected[iPattern] {
+				t.Fatalf("%+v instead of %+v", patterns[iPattern], expected[iPattern])
+			}
+		}
+	})
+}
@@ -0,0 +1,277 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+/** The ignore properties of a Subversion directory */
+type SvnIgnoreProperties struct {
+	/** The patterns of `svn:ignore`, which apply to the names of the children of the directory */
+	Ignore []string
+	/** The patterns of `svn:global-ignores`, which apply to the names of all the descendants of the directory */
+	GlobalIgnores []string
+}
+
+/**
+ * Converts gitignore patterns to the ignore properties of the directories of a Subversion working copy. Subversion only
+ * matches the names of the paths:
+ * - A pattern without a `/` (e.g. `*.o`) goes to the `svn:global-ignores` of the directory of its ignore file.
+ * - An anchored pattern (e.g. `/dist` or `src/gen`) goes to the `svn:ignore` of each directory of the tree that can
+ *   contain its paths (e.g. the root, or `src`) as its last segment.
+ *
+ * NOTE: Subversion cannot tell the files from the directories, so a directory-only pattern (e.g. `build/`) also
+ * ignores the files with the same name. The directories that do not exist in the tree, and the ones that are ignored,
+ * get no properties.
+ *
+ * @param {fs.FS} fsys The file system
+ * @param {string} root The root of the working copy. The bases of the patterns are relative to it.
+ * @param {[]Pattern} patterns The patterns
+ * @returns {(map[string]SvnIgnoreProperties, error)} The properties by the posix directory relative to root (`.` for
+ *   root), and an error that joins a `*ParseError` for each malformed pattern or pattern that Subversion cannot represent (a
+ *   negation, or a pattern with leading or trailing whitespace, which Subversion removes), which is skipped, or the
+ *   error of reading a directory
+ */
+func RenderSvnIgnore(fsys fs.FS, root string, patterns []Pattern) (map[string]SvnIgnoreProperties, error) {
+	rules, err := distributeLegacyIgnores(fsys, root, patterns, legacySvn)
+	if rules == nil {
+		return nil, err
+	}
+	properties := map[string]SvnIgnoreProperties{}
+	for _, directory := range rules.directories {
+		property := SvnIgnoreProperties{Ignore: rules.local[directory], GlobalIgnores: rules.recursive[directory]}
+		if len(property.Ignore) != 0 || len(property.GlobalIgnores) != 0 {
+			properties[directory] = property
+		}
+	}
+	return properties, err
+}
+
+/**
+ * Writes a shell script that sets the ignore properties of `RenderSvnIgnore` with `svn propset`
+ *
+ * @param {map[string]SvnIgnoreProperties} properties The properties by the posix directory relative to the root of the
+ *   working copy
+ * @returns {string} The script, which runs in the root of the working copy
+ */
+func SvnPropsetScript(properties map[string]SvnIgnoreProperties) string {
+	directories := make([]string, 0, len(properties))
+	for directory := range properties {
+		directories = append(directories, directory)
+	}
+	sort.Strings(directories)
+
+	var builder strings.Builder
+	builder.WriteString("#!/bin/sh\nset -e\n")
+	for _, directory := range directories {
+		property := properties[directory]
+		if len(property.GlobalIgnores) != 0 {
+			fmt.Fprintf(&builder, "svn propset svn:global-ignores -- %s %s\n",
+				shellQuote(strings.Join(property.GlobalIgnores, "\n")), shellQuote(directory))
+		}
+		if len(property.Ignore) != 0 {
+			fmt.Fprintf(&builder, "svn propset svn:ignore -- %s %s\n",
+				shellQuote(strings.Join(property.Ignore, "\n")), shellQuote(directory))
+		}
+	}
+	return builder.String()
+}
+
+/** Quotes a string for the POSIX shells */
+func shellQuote(str string) string {
+	return "'" + strings.ReplaceAll(str, "'", `'\''`) + "'"
+}
+
+/**
+ * Converts gitignore patterns to the `.cvsignore` files of the directories of a CVS working copy. A `.cvsignore` only
+ * applies to the names of the children of its directory, so a pattern without a `/` (e.g. `*.o`) goes to the directory
+ * of its ignore file and all of its subdirectories, and an anchored pattern goes to the directories that can contain
+ * its paths like for `RenderSvnIgnore`.
+ *
+ * NOTE: like Subversion, CVS cannot tell the files from the directories. The directories that do not exist in the
+ * tree, and the ones that are ignored, get no `.cvsignore`.
+ *
+ * @param {fs.FS} fsys The file system
+ * @param {string} root The root of the working copy. The bases of the patterns are relative to it.
+ * @param {[]Pattern} patterns The patterns
+ * @returns {(map[string]string, error)} The content of the `.cvsignore` by the posix directory relative to root (`.`
+ *   for root), and an error that joins a `*ParseError` for each malformed pattern or pattern that CVS cannot represent (a
+ *   negation, or a pattern with whitespace, which separates the patterns of CVS), which is skipped, or the error of
+ *   reading a directory
+ */
+func RenderCvsIgnore(fsys fs.FS, root string, patterns []Pattern) (map[string]string, error) {
+	rules, err := distributeLegacyIgnores(fsys, root, patterns, legacyCvs)
+	if rules == nil {
+		return nil, err
+	}
+	contents := map[string]string{}
+	for _, directory := range rules.directories {
+		globs := append([]string{}, rules.local[directory]...)
+		// the recursive patterns of the directory and of its parents
+		for ancestor := directory; ; ancestor = path.Dir(ancestor) {
+			globs = append(globs, rules.recursive[ancestor]...)
+			if ancestor == "." {
+				break
+			}
+		}
+		if len(globs) != 0 {
+			contents[directory] = strings.Join(unique(globs), "\n") + "\n"
+		}
+	}
+	return contents, err
+}
+
+/** The legacy version control systems */
+type legacyVcs uint
+
+const (
+	legacySvn legacyVcs = iota
+	legacyCvs
+)
+
+/** The name-only patterns of the directories of a tree */
+type legacyIgnores struct {
+	/** The directories of the tree that are not ignored, in the order of `fs.WalkDir` */
+	directories []string
+	/** The patterns that apply to the children of a directory */
+	local map[string][]string
+	/** The patterns that apply to all the descendants of a directory */
+	recursive map[string][]string
+}
+
+/** Distributes the patterns to the directories of the tree (see `RenderSvnIgnore`) */
+func distributeLegacyIgnores(fsys fs.FS, root string, patterns []Pattern, vcs legacyVcs) (*legacyIgnores, error) {
+	valid := make([]Pattern, 0, len(patterns))
+	errs := []error{}
+	for _, pattern := range patterns {
+		if err := checkEntry(pattern.String()); err != nil {
+			err.Source = pattern.Source
+			err.Line = pattern.Line
+			errs = append(errs, err)
+			continue
+		}
+		valid = append(valid, pattern)
+	}
+
+	matcher := NewMatcher(valid)
+	rules := &legacyIgnores{local: map[string][]string{}, recursive: map[string][]string{}}
+	err := fs.WalkDir(fsys, root, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		directory := "."
+		if name != root {
+			directory = strings.TrimPrefix(name, root+"/")
+			if root == "." {
+				directory = name
+			}
+			if entry.Name() == ".git" || entry.Name() == ".svn" || entry.Name() == "CVS" || matcher.Ignored(directory, true) {
+				return fs.SkipDir
+			}
+		}
+		rules.directories = append(rules.directories, directory)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pattern := range valid {
+		if err := rules.add(pattern, vcs); err != nil {
+			errs = append(errs, &ParseError{Source: pattern.Source, Line: pattern.Line, Pattern: pattern.String(), Err: err})
+		}
+	}
+	for directory, globs := range rules.local {
+		rules.local[directory] = unique(globs)
+	}
+	for directory, globs := range rules.recursive {
+		rules.recursive[directory] = unique(globs)
+	}
+	return rules, errors.Join(errs...)
+}
+
+/** Adds the name pattern of a pattern to the directories that can contain its paths */
+func (rules *legacyIgnores) add(pattern Pattern, vcs legacyVcs) error {
+	if pattern.Negated {
+		return fmt.Errorf("%w: the negations cannot re-include the paths", ErrUnsupportedPattern)
+	}
+	segments := splitGlobSegments(strings.TrimPrefix(pattern.Text, "/"))
+	glob := legacyGlob(segments[len(segments)-1])
+	switch {
+	case glob == "":
+		return ErrEmptyPattern
+	case vcs == legacySvn && (isWhiteSpace(glob[0]) || isWhiteSpace(glob[len(glob)-1])):
+		return fmt.Errorf("%w: Subversion removes the leading and trailing whitespace", ErrUnsupportedPattern)
+	case vcs == legacyCvs && strings.ContainsAny(glob, " \t\n\v\f\r"):
+		return fmt.Errorf("%w: CVS separates the patterns by whitespace", ErrUnsupportedPattern)
+	}
+
+	directorySegments := segments[:len(segments)-1]
+	anyDirectory := true
+	for _, segment := range directorySegments {
+		anyDirectory = anyDirectory && segment == "**"
+	}
+	base := pattern.Base
+	if base == "" {
+		base = "."
+	}
+	if !pattern.Anchored || (anyDirectory && len(directorySegments) != 0) {
+		rules.recursive[base] = append(rules.recursive[base], glob)
+		return nil
+	}
+
+	directoryGlob := strings.Join(directorySegments, "/")
+	if pattern.Base != "" {
+		directoryGlob = strings.TrimSuffix(gitIgnoreEscape(pattern.Base)+"/"+directoryGlob, "/")
+	}
+	for _, directory := range rules.directories {
+		if matchDirectoryGlob(directoryGlob, directory) {
+			rules.local[directory] = append(rules.local[directory], glob)
+		}
+	}
+	return nil
+}
+
+/** Can the directory contain the paths of the pattern whose directory part is the glob? */
+func matchDirectoryGlob(directoryGlob string, directory string) bool {
+	if directory == "." {
+		return directoryGlob == "" || directoryGlob == "**"
+	}
+	if Wildmatch(directoryGlob, directory, WildmatchPathname) {
+		return true
+	}
+	// `a/**/b` also matches `a/b`
+	return strings.HasSuffix(directoryGlob, "/**") && Wildmatch(strings.TrimSuffix(directoryGlob, "/**"), directory, WildmatchPathname)
+}
+
+/** Converts a segment of a gitignore pattern to an fnmatch glob of Subversion and CVS */
+func legacyGlob(segment string) string {
+	var builder strings.Builder
+	for i := 0; i < len(segment); i++ {
+		switch segment[i] {
+		case '\\':
+			builder.WriteString(segment[i : i+2])
+			i++
+		case '*':
+			for charAt(segment, i+1) == '*' {
+				i++
+			}
+			builder.WriteByte('*')
+		case '[':
+			end, _ := characterClassEnd(segment, i)
+			// the POSIX classes become ranges
+			builder.WriteString(renderCharacterClass(segment[i:end+1], DialectDoublestar))
+			i = end
+		default:
+			builder.WriteByte(segment[i])
+		}
+	}
+	return builder.String()
+}
@@ -0,0 +1,104 @@
+package lib
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/** A working copy with the usual directories */
+func legacyTree() fstest.MapFS {
+	return fstest.MapFS{
+		"wc/main.c":             {},
+		"wc/src/a/gen/x.c":      {},
+		"wc/src/b/x.c":          {},
+		"wc/docs/x.md":          {},
+		"wc/build/out.o":        {},
+		"wc/.svn/wc.db":         {},
+		"wc/sub/CVS/Entries":    {},
+		"wc/sub/deep/x.c":       {},
+		"wc/sub/deep/er/more.c": {},
+	}
+}
+
+func TestRenderSvnIgnore(t *testing.T) {
+	patterns := ParseGitIgnore("*.o\n/build/\nsrc/*/gen\ndocs/**/*.tmp\n[[:digit:]]*.bak\n**/cache\n")
+	patterns = append(patterns, ParseGitIgnore("*.log\n/local\n", "sub")...)
+	properties, err := RenderSvnIgnore(legacyTree(), "wc", patterns)
+	assert.Nil(t, err)
+	assert.Equal(t, properties, map[string]SvnIgnoreProperties{
+		".":     {Ignore: []string{"build"}, GlobalIgnores: []string{"*.o", "[0-9]*.bak", "cache"}},
+		"src/a": {Ignore: []string{"gen"}},
+		"src/b": {Ignore: []string{"gen"}},
+		"docs":  {Ignore: []string{"*.tmp"}},
+		"sub":   {Ignore: []string{"local"}, GlobalIgnores: []string{"*.log"}},
+	})
+
+	assert.Equal(t, SvnPropsetScript(properties), `#!/bin/sh
+set -e
+svn propset svn:global-ignores -- '*.o
+[0-9]*.bak
+cache' '.'
+svn propset svn:ignore -- 'build' '.'
+svn propset svn:ignore -- '*.tmp' 'docs'
+svn propset svn:ignore -- 'gen' 'src/a'
+svn propset svn:ignore -- 'gen' 'src/b'
+svn propset svn:global-ignores -- '*.log' 'sub'
+svn propset svn:ignore -- 'local' 'sub'
+`)
+	assert.Equal(t, shellQuote("it's"), `'it'\''s'`)
+}
+
+func TestRenderSvnIgnoreErrors(t *testing.T) {
+	properties, err := RenderSvnIgnore(legacyTree(), "wc", ParseGitIgnore("*.o\n!keep.o\n lead\ntrail\\ \nin side\n\\ escaped\n"))
+	assert.Equal(t, properties, map[string]SvnIgnoreProperties{".": {GlobalIgnores: []string{"*.o", "in side", "\\ escaped"}}})
+	assert.True(t, errors.Is(err, ErrUnsupportedPattern))
+	assert.Equal(t, strings.Split(err.Error(), "\n"), []string{
+		`line 2: "!keep.o": unsupported pattern: the negations cannot re-include the paths`,
+		`line 3: " lead": unsupported pattern: Subversion removes the leading and trailing whitespace`,
+		`line 4: "trail\\ ": unsupported pattern: Subversion removes the leading and trailing whitespace`,
+	})
+
+	// the malformed patterns are skipped
+	properties, err = RenderSvnIgnore(fstest.MapFS{"a/x": {}}, ".", ParseGitIgnore("foo\\\nfoo[\n/a/[\n*.o\n"))
+	assert.Equal(t, properties, map[string]SvnIgnoreProperties{".": {GlobalIgnores: []string{"*.o"}}})
+	assert.Equal(t, strings.Split(err.Error(), "\n"), []string{
+		`line 1, column 4: "foo\\": trailing backslash`,
+		`line 2, column 4: "foo[": unterminated character class`,
+		`line 3, column 4: "/a/[": unterminated character class`,
+	})
+
+	_, err = RenderSvnIgnore(legacyTree(), "missing", nil)
+	assert.NotNil(t, err)
+}
+
+func TestRenderCvsIgnore(t *testing.T) {
+	patterns := ParseGitIgnore("*.o\n/build/\nsrc/*/gen\n")
+	patterns = append(patterns, ParseGitIgnore("*.log\n/local\n", "sub")...)
+	contents, err := RenderCvsIgnore(legacyTree(), "wc", patterns)
+	assert.Nil(t, err)
+	assert.Equal(t, contents, map[string]string{
+		".":           "build\n*.o\n",
+		"docs":        "*.o\n",
+		"src":         "*.o\n",
+		"src/a":       "gen\n*.o\n",
+		"src/b":       "gen\n*.o\n",
+		"sub":         "local\n*.log\n*.o\n",
+		"sub/deep":    "*.log\n*.o\n",
+		"sub/deep/er": "*.log\n*.o\n",
+	})
+
+	contents, err = RenderCvsIgnore(fstest.MapFS{"main.c": {}}, ".", ParseGitIgnore("*.o\nin side\n!x\n"))
+	assert.Equal(t, contents, map[string]string{".": "*.o\n"})
+	assert.Equal(t, strings.Split(err.Error(), "\n"), []string{
+		`line 2: "in side": unsupported pattern: CVS separates the patterns by whitespace`,
+		`line 3: "!x": unsupported pattern: the negations cannot re-include the paths`,
+	})
+
+	contents, err = RenderCvsIgnore(fstest.MapFS{"a/x": {}}, ".", ParseGitIgnore("*.o\nfoo\\\na/[\n"))
+	assert.Equal(t, contents, map[string]string{".": "*.o\n", "a": "*.o\n"})
+	assert.Equal(t, len(strings.Split(err.Error(), "\n")), 2)
+}
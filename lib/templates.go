@@ -0,0 +1,195 @@
+package lib
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+/** A snapshot of github/gitignore, with its `Global/` and `community/` templates (see templates/README.md) */
+//go:embed templates
+var templateFiles embed.FS
+
+const templateDirectory = "templates"
+
+/** An embedded gitignore template */
+type embeddedTemplate struct {
+	/** The name of the template (e.g. `Go`, `JetBrains` or `Global/AL`) */
+	name string
+	/** The path of the template in the collection without the extension (e.g. `Global/JetBrains`) */
+	path string
+}
+
+/**
+ * Get the embedded gitignore templates. A template is named by its file name, unless another template has the same
+ * file name and is at the top level of the collection or in another directory, then by its path (e.g. `Global/AL` for
+ * `AL`).
+ */
+func embeddedTemplates() []embeddedTemplate {
+	paths := []string{}
+	baseNames := map[string]int{}
+	_ = fs.WalkDir(templateFiles, templateDirectory, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || !strings.HasSuffix(filePath, gitIgnoreFileName) {
+			return err
+		}
+		templatePath := strings.TrimSuffix(strings.TrimPrefix(filePath, templateDirectory+"/"), gitIgnoreFileName)
+		paths = append(paths, templatePath)
+		baseNames[strings.ToLower(path.Base(templatePath))]++
+		return nil
+	})
+
+	templates := []embeddedTemplate{}
+	for _, templatePath := range paths {
+		name := path.Base(templatePath)
+		if name != templatePath && baseNames[strings.ToLower(name)] != 1 {
+			name = templatePath
+		}
+		templates = append(templates, embeddedTemplate{name: name, path: templatePath})
+	}
+	return templates
+}
+
+/**
+ * Get the names of the embedded gitignore templates (e.g. `Go`, `JetBrains` or `Global/AL`)
+ *
+ * @returns {[]string} The names sorted case-insensitively
+ */
+func TemplateNames() []string {
+	names := []string{}
+	for _, template := range embeddedTemplates() {
+		names = append(names, template.name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return strings.ToLower(names[i]) < strings.ToLower(names[j])
+	})
+	return names
+}
+
+/**
+ * Searches the embedded gitignore templates
+ *
+ * @param {string} query The text to search in the names, case-insensitively
+ * @returns {[]string} The names that contain the query, sorted case-insensitively. All the names for an empty query.
+ */
+func SearchTemplates(query string) []string {
+	query = strings.ToLower(query)
+	names := []string{}
+	for _, name := range TemplateNames() {
+		if strings.Contains(strings.ToLower(name), query) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+/**
+ * Get an embedded gitignore template
+ *
+ * @param {string} name The name of the template, or its path in the collection (e.g. `Global/JetBrains`),
+ *   case-insensitively (e.g. `go` for `Go`)
+ * @returns {(string, string, error)} The name of the template, its content, or `ErrUnknownTemplate`
+ */
+func Template(name string) (string, string, error) {
+	for _, template := range embeddedTemplates() {
+		if !strings.EqualFold(template.name, name) && !strings.EqualFold(template.path, name) {
+			continue
+		}
+		content, err := fs.ReadFile(templateFiles, path.Join(templateDirectory, template.path+gitIgnoreFileName))
+		if err != nil {
+			return "", "", err
+		}
+		return template.name, string(content), nil
+	}
+	return "", "", fmt.Errorf("%w %q", ErrUnknownTemplate, name)
+}
+
+/**
+ * Composes a gitignore from embedded templates. Each template starts with a `### Name ###` header, and its comments
+ * are kept. A pattern that an earlier template already has is removed, unless a pattern of the other polarity (e.g.
+ * `!keep.log` for `*.log`) came after it, as the pattern would then decide differently.
+ *
+ * @param {[]string} names The names of the templates, case-insensitively. The repeated names are skipped.
+ * @returns {(string, error)} The content of the gitignore, or `ErrUnknownTemplate`
+ */
+func ComposeTemplates(names []string) (string, error) {
+	composer := &templateComposer{emitted: map[string]int{}, lastNegated: -1, lastIgnored: -1}
+	var builder strings.Builder
+	composed := map[string]bool{}
+	for _, name := range names {
+		templateName, content, err := Template(name)
+		if err != nil {
+			return "", err
+		}
+		if composed[templateName] {
+			continue
+		}
+		composed[templateName] = true
+
+		if builder.Len() != 0 {
+			builder.WriteByte('\n')
+		}
+		fmt.Fprintf(&builder, "### %s ###\n", templateName)
+		builder.WriteString(composer.compose(content))
+	}
+	return builder.String(), nil
+}
+
+/** Removes the repeated patterns of the templates */
+type templateComposer struct {
+	/** The index of the last emission of a pattern */
+	emitted map[string]int
+	/** The index of the last emitted negated pattern, or -1 */
+	lastNegated int
+	/** The index of the last emitted non-negated pattern, or -1 */
+	lastIgnored int
+	count       int
+}
+
+/** Get the lines of a template without its repeated patterns and repeated blank lines */
+func (composer *templateComposer) compose(content string) string {
+	lines := []string{}
+	for _, line := range strings.Split(strings.TrimPrefix(content, byteOrderMark), "\n") {
+		line = trimCarriageReturn(line)
+		pattern, ok := ParseGitIgnorePattern(line)
+		if !ok {
+			if strings.TrimSpace(line) != "" || (len(lines) != 0 && lines[len(lines)-1] != "") {
+				lines = append(lines, line)
+			}
+			continue
+		}
+		if composer.emit(pattern) {
+			lines = append(lines, line)
+		}
+	}
+	for len(lines) != 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+/** Should the pattern be written? */
+func (composer *templateComposer) emit(pattern Pattern) bool {
+	key := CanonicalGlob(pattern.String())
+	if index, ok := composer.emitted[key]; ok {
+		if pattern.Negated && composer.lastIgnored < index {
+			return false
+		}
+		if !pattern.Negated && composer.lastNegated < index {
+			return false
+		}
+	}
+	composer.emitted[key] = composer.count
+	if pattern.Negated {
+		composer.lastNegated = composer.count
+	} else {
+		composer.lastIgnored = composer.count
+	}
+	composer.count++
+	return true
+}
@@ -0,0 +1,87 @@
+package lib
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplateNames(t *testing.T) {
+	names := TemplateNames()
+	assert.Contains(t, names, "Go")
+	assert.Contains(t, names, "JetBrains")
+	assert.Contains(t, names, "C++")
+	assert.Contains(t, names, "Global/AL")
+	assert.Contains(t, names, "Hugo")
+	// case-insensitively sorted
+	for i := 1; i < len(names); i++ {
+		assert.True(t, strings.ToLower(names[i-1]) < strings.ToLower(names[i]), names[i])
+	}
+
+	// the templates are well-formed
+	for _, name := range names {
+		_, content, err := Template(name)
+		assert.Nil(t, err, name)
+		_, err = GlobifyGitIgnoreWithOptions(content, GlobifyOptions{Strict: true})
+		assert.Nil(t, err, name)
+	}
+}
+
+func TestSearchTemplates(t *testing.T) {
+	assert.Equal(t, SearchTemplates("visualstudio"), []string{"VisualStudio", "VisualStudioCode"})
+	// the templates whose file names are repeated are named by their paths
+	assert.Equal(t, SearchTemplates("/"), []string{"community/BoxLang/ColdBox", "community/CFML/ColdBox", "community/Racket", "Global/AL"})
+	assert.Equal(t, SearchTemplates("JET"), []string{"JetBrains"})
+	assert.Equal(t, SearchTemplates("cobol"), []string{})
+	assert.Equal(t, SearchTemplates(""), TemplateNames())
+}
+
+func TestTemplate(t *testing.T) {
+	name, content, err := Template("go")
+	assert.Nil(t, err)
+	assert.Equal(t, name, "Go")
+	assert.Contains(t, content, "\ngo.work\n")
+
+	// the templates of Global/ and community/ can also be read with their paths
+	name, _, err = Template("global/jetbrains")
+	assert.Nil(t, err)
+	assert.Equal(t, name, "JetBrains")
+	name, _, err = Template("AL")
+	assert.Nil(t, err)
+	assert.Equal(t, name, "AL")
+	name, _, err = Template("Global/AL")
+	assert.Nil(t, err)
+	assert.Equal(t, name, "Global/AL")
+	_, _, err = Template("ColdBox")
+	assert.True(t, errors.Is(err, ErrUnknownTemplate))
+
+	_, _, err = Template("Cobol")
+	assert.True(t, errors.Is(err, ErrUnknownTemplate))
+	assert.Equal(t, err.Error(), `unknown template "Cobol"`)
+}
+
+func TestComposeTemplates(t *testing.T) {
+	content, err := ComposeTemplates([]string{"c", "C++", "c"})
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(content, "### C ###\n# Prerequisites\n*.d\n"))
+	sections := strings.Split(content, "\n### C++ ###\n")
+	assert.Equal(t, len(sections), 2)
+	// the section headers of the templates are kept, but their patterns are not repeated
+	assert.True(t, strings.HasPrefix(sections[1], "# Prerequisites\n\n# Compiled Object files\n*.slo\n\n# Precompiled Headers\n\n"), sections[1])
+	assert.NotContains(t, sections[1], "\n*.o\n")
+	assert.Contains(t, sections[1], "\n*.lai\n")
+
+	_, err = ComposeTemplates([]string{"Go", "Cobol"})
+	assert.True(t, errors.Is(err, ErrUnknownTemplate))
+}
+
+func TestTemplateComposer(t *testing.T) {
+	composer := &templateComposer{emitted: map[string]int{}, lastNegated: -1, lastIgnored: -1}
+	assert.Equal(t, composer.compose("# Logs\n*.log\n\n\n!keep.log\na//b\n\n"), "# Logs\n*.log\n\n!keep.log\na//b\n")
+	// the patterns are repeated when a pattern of the other polarity came after them
+	assert.Equal(t, composer.compose("*.log\n!keep.log\na/b\n/x/\n"), "*.log\n!keep.log\na/b\n/x/\n")
+	assert.Equal(t, composer.compose("/x/\r\na//b\r\n*.log\r\n"), "*.log\n")
+	assert.Equal(t, composer.compose("*.log\n!keep.log\n"), "!keep.log\n")
+}
@@ -0,0 +1,308 @@
+package lib
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"sync"
+)
+
+/** The options of `Walk` */
+type WalkOptions struct {
+	/** Descend into the symbolic links to directories. Git never follows them, so this is off by default. */
+	FollowSymlinks bool
+	/** Include the files and directories whose name starts with a dot */
+	Hidden bool
+	/** The patterns that apply before the ignore files of the tree (e.g. the patterns of `.git/info/exclude`) */
+	Matcher *Matcher
+	/** The name of the per-directory ignore file. Defaults to `.gitignore` */
+	IgnoreFileName string
+	/** Compare the ASCII letters case-insensitively like git with `core.ignoreCase` (see `DetectIgnoreCase`) */
+	IgnoreCase bool
+	/** Convert the patterns and the paths to a Unicode normalization form before matching (see `Normalization`) */
+	Normalization Normalization
+}
+
+/**
+ * Walks the file tree rooted at root like `fs.WalkDir`, but skips the paths ignored by the `.gitignore` files of the
+ * tree. The ignore files are loaded while descending, and the ignored directories are pruned without reading them.
+ * The `.git` directory is always skipped.
+ *
+ * @param {fs.FS} fsys The file system to walk
+ * @param {string} root The directory to walk. The ignore patterns are relative to it.
+ * @param {fs.WalkDirFunc} fn The function called for the root and each non-ignored entry
+ * @param {Optional WalkOptions} options The options of the walk
+ * @returns {error} The error returned by fn
+ */
+func Walk(fsys fs.FS, root string, fn fs.WalkDirFunc, options ...WalkOptions) error {
+	walkOptions := WalkOptions{}
+	if len(options) == 1 {
+		walkOptions = options[0]
+	}
+	walker := &walker{tree: NewIgnoreTree(fsys, root, walkOptions), fn: fn}
+	err := walker.walk(root)
+	if walker.skipAll {
+		return nil
+	}
+	return err
+}
+
+type walker struct {
+	tree *IgnoreTree
+	fn   fs.WalkDirFunc
+	/** fn returned fs.SkipAll inside a followed symbolic link */
+	skipAll bool
+}
+
+func (walker *walker) walk(start string) error {
+	tree := walker.tree
+	return fs.WalkDir(tree.fsys, start, func(name string, entry fs.DirEntry, err error) error {
+		if name == start && start != tree.root {
+			// a followed symbolic link that is already reported
+			if err != nil {
+				return walker.call(name, entry, err)
+			}
+			return nil
+		}
+		if err != nil || name == tree.root {
+			return walker.call(name, entry, err)
+		}
+
+		ignored, isDir, followed, err := tree.check(name, entry)
+		if err != nil {
+			return walker.call(name, entry, err)
+		}
+		if ignored {
+			if isDir && !followed {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if !followed {
+			return walker.call(name, entry, nil)
+		}
+		info, err := fs.Stat(tree.fsys, name)
+		if err != nil {
+			return walker.call(name, entry, err)
+		}
+		if err := walker.call(name, fs.FileInfoToDirEntry(info), nil); err != nil {
+			if err == fs.SkipDir {
+				return nil
+			}
+			return err
+		}
+		if tree.isLoop(name, info) {
+			return nil
+		}
+		if err := walker.walk(name); err != nil || walker.skipAll {
+			if walker.skipAll {
+				return fs.SkipAll
+			}
+			return err
+		}
+		return nil
+	})
+}
+
+func (walker *walker) call(name string, entry fs.DirEntry, err error) error {
+	err = walker.fn(name, entry, err)
+	if err == fs.SkipAll {
+		walker.skipAll = true
+	}
+	return err
+}
+
+/**
+ * The ignore rules of the directories of a file tree. The ignore files are loaded lazily and cached, so a tree should
+ * not outlive the changes of the files. It is safe for concurrent use.
+ */
+type IgnoreTree struct {
+	fsys     fs.FS
+	root     string
+	options  WalkOptions
+	mutex    sync.Mutex
+	matchers map[string]*Matcher
+}
+
+/**
+ * Creates the ignore rules of a file tree
+ *
+ * @param {fs.FS} fsys The file system
+ * @param {string} root The directory of the tree. The ignore patterns are relative to it.
+ * @param {Optional WalkOptions} options The `Matcher`, the `IgnoreFileName`, the `IgnoreCase` and the `Normalization`
+ *   options are used
+ * @returns {*IgnoreTree}
+ */
+func NewIgnoreTree(fsys fs.FS, root string, options ...WalkOptions) *IgnoreTree {
+	treeOptions := WalkOptions{}
+	if len(options) == 1 {
+		treeOptions = options[0]
+	}
+	if treeOptions.IgnoreFileName == "" {
+		treeOptions.IgnoreFileName = gitIgnoreFileName
+	}
+	return &IgnoreTree{
+		fsys:     fsys,
+		root:     root,
+		options:  treeOptions,
+		matchers: map[string]*Matcher{},
+	}
+}
+
+const gitIgnoreFileName = ".gitignore"
+
+/** Converts a path of the file system to a posix path relative to the root */
+func (tree *IgnoreTree) relative(name string) string {
+	if tree.root == "." {
+		return name
+	}
+	return strings.TrimPrefix(strings.TrimPrefix(name, tree.root), "/")
+}
+
+/** Converts a posix path relative to the root to a path of the file system */
+func (tree *IgnoreTree) join(relative string) string {
+	if relative == "" {
+		return tree.root
+	}
+	return path.Join(tree.root, relative)
+}
+
+/**
+ * Get the matcher that has the patterns of the directory and all of its parents
+ *
+ * @param {string} directory The posix directory relative to the root. Empty for the root.
+ */
+func (tree *IgnoreTree) Matcher(directory string) (*Matcher, error) {
+	tree.mutex.Lock()
+	matcher, ok := tree.matchers[directory]
+	tree.mutex.Unlock()
+	if ok {
+		return matcher, nil
+	}
+
+	parent := tree.options.Matcher
+	if directory == "" && tree.options.IgnoreCase {
+		parent = parent.WithIgnoreCase(true)
+	}
+	if directory == "" && tree.options.Normalization != NormalizationNone {
+		parent = parent.WithNormalization(tree.options.Normalization)
+	}
+	if directory != "" {
+		var err error
+		parent, err = tree.Matcher(parentDirectory(directory))
+		if err != nil {
+			return parent, err
+		}
+	}
+	patterns, err := tree.readIgnoreFile(directory)
+	if err != nil {
+		return parent, err
+	}
+	matcher = parent.Append(patterns)
+
+	tree.mutex.Lock()
+	tree.matchers[directory] = matcher
+	tree.mutex.Unlock()
+	return matcher, nil
+}
+
+/**
+ * Get the pattern that decides whether the path is ignored (see `Matcher.LastMatchingPattern`). Only the ignore files
+ * of the parent directories of the path are read. The path does not need to exist.
+ *
+ * @param {string} name The posix path relative to the root
+ * @param {bool} isDir Whether the path is a directory
+ * @returns {(*Pattern, error)} The pattern or nil if no pattern matches, or the error of reading an ignore file
+ */
+func (tree *IgnoreTree) LastMatchingPattern(name string, isDir bool) (*Pattern, error) {
+	name = strings.Trim(PosixifyPath(name), "/")
+	// like git, each parent directory is checked with the patterns of its own parents
+	for iSlash := 0; iSlash < len(name); iSlash++ {
+		if name[iSlash] != '/' {
+			continue
+		}
+		matcher, err := tree.Matcher(parentDirectory(name[:iSlash]))
+		if err != nil {
+			return nil, err
+		}
+		if pattern := matcher.MatchingPattern(name[:iSlash], true); pattern != nil && !pattern.Negated {
+			return pattern, nil
+		}
+	}
+	matcher, err := tree.Matcher(parentDirectory(name))
+	if err != nil {
+		return nil, err
+	}
+	return matcher.MatchingPattern(name, isDir), nil
+}
+
+/** Reads the patterns of the ignore file of the directory. A missing ignore file has no patterns. */
+func (tree *IgnoreTree) readIgnoreFile(directory string) ([]Pattern, error) {
+	source := path.Join(directory, tree.options.IgnoreFileName)
+	content, err := fs.ReadFile(tree.fsys, tree.join(source))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	patterns := ParseGitIgnore(string(content), directory)
+	for iPattern := range patterns {
+		patterns[iPattern].Source = source
+	}
+	return patterns, nil
+}
+
+/**
+ * Checks an entry whose parent directory is not ignored
+ *
+ * @returns {(bool, bool, bool, error)} If it is ignored, if it is a directory, and if it is a symbolic link to a
+ *   directory that should be followed
+ */
+func (tree *IgnoreTree) check(name string, entry fs.DirEntry) (bool, bool, bool, error) {
+	baseName := entry.Name()
+	if baseName == ".git" || (!tree.options.Hidden && strings.HasPrefix(baseName, ".")) {
+		return true, entry.IsDir(), false, nil
+	}
+
+	isDir := entry.IsDir()
+	followed := false
+	if tree.options.FollowSymlinks && PathTypeOfMode(entry.Type()) == PathTypeSymlink {
+		if info, err := fs.Stat(tree.fsys, name); err == nil && info.IsDir() {
+			isDir = true
+			followed = true
+		}
+	}
+
+	relative := tree.relative(name)
+	matcher, err := tree.Matcher(parentDirectory(relative))
+	if err != nil {
+		return false, isDir, followed, err
+	}
+	pattern := matcher.MatchingPattern(relative, isDir)
+	return pattern != nil && !pattern.Negated, isDir, followed, nil
+}
+
+/** Is the followed directory one of the ancestors of the path? */
+func (tree *IgnoreTree) isLoop(name string, info fs.FileInfo) bool {
+	ancestor := name
+	for ancestor != "." {
+		ancestor = path.Dir(ancestor)
+		if ancestorInfo, err := fs.Stat(tree.fsys, ancestor); err == nil && os.SameFile(ancestorInfo, info) {
+			return true
+		}
+	}
+	return false
+}
+
+/** The parent of a posix path relative to the root. Empty for the root. */
+func parentDirectory(relative string) string {
+	directory := path.Dir(relative)
+	if directory == "." {
+		return ""
+	}
+	return directory
+}
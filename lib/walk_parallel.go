@@ -0,0 +1,296 @@
+package lib
+
+import (
+	"context"
+	"io/fs"
+	"path"
+	"runtime"
+	"sync"
+)
+
+/** The options of `WalkParallel` */
+type ParallelWalkOptions struct {
+	WalkOptions
+	/** The maximum number of directories that are read at the same time. Defaults to `runtime.GOMAXPROCS(0)`. */
+	Workers int
+	/**
+	 * Emit the entries in the lexical depth-first order of `Walk` instead of the order they are read. The directories
+	 * are still read ahead in parallel, so the entries that wait for a slower sibling are buffered.
+	 */
+	Sorted bool
+	/**
+	 * The maximum number of directories that are read ahead of the consumer in the sorted mode, so a slow consumer
+	 * does not buffer the whole tree. The other directories are read when the consumer reaches them. Defaults to
+	 * `4 * Workers`.
+	 */
+	ReadAhead int
+}
+
+/** An entry emitted by `WalkParallel` */
+type WalkEntry struct {
+	/** The path of the entry in the file system (joined with the root like `fs.WalkDir`) */
+	Path string
+	/** The entry. It may be nil if `Err` is not nil. */
+	Entry fs.DirEntry
+	/** The error of reading the entry or its directory (like the err argument of `fs.WalkDirFunc`) */
+	Err error
+}
+
+/**
+ * Walks the file tree rooted at root with a pool of workers that read the directories and their ignore files in
+ * parallel. Like `Walk`, the ignored paths are skipped and the ignored directories are not read.
+ *
+ * The root is emitted first. The errors are emitted as entries with `Err`, and the walk goes on. The channel is closed
+ * when the walk is done or the context is cancelled, so the caller should either drain it or cancel the context.
+ *
+ * @param {context.Context} ctx The context that cancels the walk
+ * @param {fs.FS} fsys The file system to walk
+ * @param {string} root The directory to walk. The ignore patterns are relative to it.
+ * @param {Optional ParallelWalkOptions} options The options of the walk
+ * @returns {<-chan WalkEntry} The channel of the non-ignored entries
+ */
+func WalkParallel(ctx context.Context, fsys fs.FS, root string, options ...ParallelWalkOptions) <-chan WalkEntry {
+	walkOptions := ParallelWalkOptions{}
+	if len(options) == 1 {
+		walkOptions = options[0]
+	}
+	if walkOptions.Workers <= 0 {
+		walkOptions.Workers = runtime.GOMAXPROCS(0)
+	}
+	if walkOptions.ReadAhead <= 0 {
+		walkOptions.ReadAhead = 4 * walkOptions.Workers
+	}
+
+	walker := &parallelWalker{
+		ctx:    ctx,
+		tree:   NewIgnoreTree(fsys, root, walkOptions.WalkOptions),
+		sorted: walkOptions.Sorted,
+		out:    make(chan WalkEntry, walkOptions.Workers),
+		slots:  make(chan struct{}, walkOptions.ReadAhead),
+	}
+	walker.cond = sync.NewCond(&walker.mutex)
+	go walker.run(walkOptions.Workers)
+	return walker.out
+}
+
+type parallelWalker struct {
+	ctx    context.Context
+	tree   *IgnoreTree
+	sorted bool
+	out    chan WalkEntry
+
+	mutex sync.Mutex
+	cond  *sync.Cond
+	/** The directories waiting for a worker. It is used as a stack to keep the walk close to depth-first. */
+	queue []*directoryJob
+	/** The number of directories that are queued or being read, plus one while the sorted entries are emitted */
+	pending int
+	/** The directories that are queued or read ahead of the consumer in the sorted mode */
+	slots chan struct{}
+}
+
+/** A directory that is read by a worker */
+type directoryJob struct {
+	name string
+	/** Closed when `children` and `err` are set */
+	done     chan struct{}
+	children []walkChild
+	err      error
+	/** The directory is not queued, as too many directories are read ahead in the sorted mode, so `emit` reads it */
+	deferred bool
+}
+
+type walkChild struct {
+	entry WalkEntry
+	/** The job of the child directory, or nil if it is not descended */
+	job *directoryJob
+}
+
+func newDirectoryJob(name string) *directoryJob {
+	return &directoryJob{name: name, done: make(chan struct{})}
+}
+
+func (walker *parallelWalker) run(workers int) {
+	defer close(walker.out)
+
+	tree := walker.tree
+	info, err := fs.Stat(tree.fsys, tree.root)
+	if err != nil {
+		walker.send(WalkEntry{Path: tree.root, Err: err})
+		return
+	}
+	if !walker.send(WalkEntry{Path: tree.root, Entry: fs.FileInfoToDirEntry(info)}) || !info.IsDir() {
+		return
+	}
+
+	// wake up the idle workers when the walk is cancelled
+	stop := context.AfterFunc(walker.ctx, func() {
+		walker.mutex.Lock()
+		walker.cond.Broadcast()
+		walker.mutex.Unlock()
+	})
+	defer stop()
+
+	rootJob := newDirectoryJob(tree.root)
+	if walker.sorted {
+		// the workers wait for the directories that are queued while emitting
+		walker.pending++
+		rootJob.deferred = true
+	} else {
+		walker.enqueue(rootJob)
+	}
+
+	var workerGroup sync.WaitGroup
+	workerGroup.Add(workers)
+	for iWorker := 0; iWorker < workers; iWorker++ {
+		go func() {
+			defer workerGroup.Done()
+			walker.work()
+		}()
+	}
+
+	if walker.sorted {
+		walker.emit(rootJob)
+		walker.mutex.Lock()
+		walker.pending--
+		walker.cond.Broadcast()
+		walker.mutex.Unlock()
+		// the remaining workers exit on their own once the context is cancelled or the queue is drained
+		return
+	}
+	workerGroup.Wait()
+}
+
+/** Sends an entry unless the walk is cancelled */
+func (walker *parallelWalker) send(entry WalkEntry) bool {
+	select {
+	case walker.out <- entry:
+		return true
+	case <-walker.ctx.Done():
+		return false
+	}
+}
+
+func (walker *parallelWalker) enqueue(job *directoryJob) {
+	walker.mutex.Lock()
+	walker.queue = append(walker.queue, job)
+	walker.pending++
+	walker.mutex.Unlock()
+	walker.cond.Signal()
+}
+
+func (walker *parallelWalker) work() {
+	for {
+		walker.mutex.Lock()
+		for len(walker.queue) == 0 && walker.pending != 0 && walker.ctx.Err() == nil {
+			walker.cond.Wait()
+		}
+		if len(walker.queue) == 0 || walker.ctx.Err() != nil {
+			walker.mutex.Unlock()
+			return
+		}
+		job := walker.queue[len(walker.queue)-1]
+		walker.queue = walker.queue[:len(walker.queue)-1]
+		walker.mutex.Unlock()
+
+		walker.read(job)
+
+		walker.mutex.Lock()
+		walker.pending--
+		if walker.pending == 0 {
+			walker.cond.Broadcast()
+		}
+		walker.mutex.Unlock()
+	}
+}
+
+/** Reads a directory, queues its non-ignored subdirectories, and emits its entries in the unsorted mode */
+func (walker *parallelWalker) read(job *directoryJob) {
+	defer close(job.done)
+	tree := walker.tree
+
+	entries, err := fs.ReadDir(tree.fsys, job.name)
+	if err != nil {
+		job.err = err
+		if !walker.sorted {
+			walker.send(WalkEntry{Path: job.name, Err: err})
+		}
+		return
+	}
+
+	for _, entry := range entries {
+		name := path.Join(job.name, entry.Name())
+		child := walkChild{entry: WalkEntry{Path: name, Entry: entry}}
+
+		ignored, isDir, followed, err := tree.check(name, entry)
+		if ignored {
+			continue
+		}
+		if err == nil && followed {
+			var info fs.FileInfo
+			if info, err = fs.Stat(tree.fsys, name); err == nil {
+				child.entry.Entry = fs.FileInfoToDirEntry(info)
+				isDir = !tree.isLoop(name, info)
+			}
+		}
+		if err != nil {
+			child.entry.Err = err
+		} else if isDir {
+			child.job = newDirectoryJob(name)
+			if walker.reserve() {
+				walker.enqueue(child.job)
+			} else {
+				child.job.deferred = true
+			}
+		}
+
+		if walker.sorted {
+			job.children = append(job.children, child)
+		} else if !walker.send(child.entry) {
+			return
+		}
+	}
+}
+
+/** Reserves a slot to read a directory ahead of the consumer. The slots are unlimited in the unsorted mode. */
+func (walker *parallelWalker) reserve() bool {
+	if !walker.sorted {
+		return true
+	}
+	select {
+	case walker.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+/** Emits the entries of the directory and its descendants in order */
+func (walker *parallelWalker) emit(job *directoryJob) bool {
+	if job.deferred {
+		if walker.ctx.Err() != nil {
+			return false
+		}
+		walker.read(job)
+	} else {
+		select {
+		case <-job.done:
+		case <-walker.ctx.Done():
+			return false
+		}
+		// the consumer reached the directory
+		<-walker.slots
+	}
+	if job.err != nil {
+		return walker.send(WalkEntry{Path: job.name, Err: job.err})
+	}
+	for _, child := range job.children {
+		if !walker.send(child.entry) {
+			return false
+		}
+		if child.job != nil && !walker.emit(child.job) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,119 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func collectParallel(t *testing.T, entries <-chan WalkEntry) []string {
+	paths := []string{}
+	for entry := range entries {
+		assert.Nil(t, entry.Err)
+		paths = append(paths, entry.Path)
+	}
+	return paths
+}
+
+func largeTestTree() fstest.MapFS {
+	fsys := fstest.MapFS{
+		".gitignore": {Data: []byte("*.log\nskip/\n")},
+	}
+	for iDir := 0; iDir < 20; iDir++ {
+		for iSub := 0; iSub < 5; iSub++ {
+			directory := fmt.Sprintf("dir%d/sub%d", iDir, iSub)
+			fsys[directory+"/file.txt"] = &fstest.MapFile{}
+			fsys[directory+"/file.log"] = &fstest.MapFile{}
+			fsys[directory+"/skip/file.txt"] = &fstest.MapFile{}
+		}
+		fsys[fmt.Sprintf("dir%d/.gitignore", iDir)] = &fstest.MapFile{Data: []byte("sub0/\n!*.log\n")}
+	}
+	return fsys
+}
+
+func TestWalkParallelSorted(t *testing.T) {
+	for _, fsys := range []fstest.MapFS{testTree(), largeTestTree()} {
+		expected := walkPaths(t, fsys, ".", WalkOptions{Hidden: true})
+		for _, workers := range []int{1, 4, 16} {
+			entries := WalkParallel(context.Background(), fsys, ".", ParallelWalkOptions{
+				WalkOptions: WalkOptions{Hidden: true},
+				Workers:     workers,
+				Sorted:      true,
+			})
+			assert.Equal(t, collectParallel(t, entries), expected)
+		}
+	}
+}
+
+/** A file system that counts the directories that are read */
+type readDirCountingFS struct {
+	fstest.MapFS
+	readDirs atomic.Int32
+}
+
+func (fsys *readDirCountingFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	fsys.readDirs.Add(1)
+	return fsys.MapFS.ReadDir(name)
+}
+
+func TestWalkParallelSortedReadAhead(t *testing.T) {
+	fsys := &readDirCountingFS{MapFS: largeTestTree()}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	entries := WalkParallel(ctx, fsys, ".", ParallelWalkOptions{Workers: 2, Sorted: true, ReadAhead: 3})
+	<-entries
+	// the consumer is blocked, so only the directories that are emitted (the root, and at most the 2 buffered entries)
+	// and the 3 directories of the read-ahead are read
+	time.Sleep(100 * time.Millisecond)
+	assert.True(t, fsys.readDirs.Load() <= 1+2+3, fsys.readDirs.Load())
+
+	// the other directories are read when the consumer reaches them
+	paths := collectParallel(t, entries)
+	assert.Equal(t, append([]string{"."}, paths...), walkPaths(t, fsys.MapFS, "."))
+	assert.Equal(t, int(fsys.readDirs.Load()), 1+20*5)
+}
+
+func TestWalkParallelUnsorted(t *testing.T) {
+	fsys := largeTestTree()
+	expected := walkPaths(t, fsys, ".")
+	paths := collectParallel(t, WalkParallel(context.Background(), fsys, "."))
+	assert.Equal(t, paths[0], ".")
+	sort.Strings(expected)
+	sort.Strings(paths)
+	assert.Equal(t, paths, expected)
+}
+
+func TestWalkParallelCancel(t *testing.T) {
+	for _, sorted := range []bool{false, true} {
+		ctx, cancel := context.WithCancel(context.Background())
+		entries := WalkParallel(ctx, largeTestTree(), ".", ParallelWalkOptions{Workers: 4, Sorted: sorted})
+		<-entries
+		<-entries
+		cancel()
+		// the channel is closed after the cancellation
+		for range entries {
+		}
+	}
+}
+
+func TestWalkParallelErrors(t *testing.T) {
+	entries := collectParallelErrors(WalkParallel(context.Background(), testTree(), "missing"))
+	assert.Equal(t, len(entries), 1)
+	assert.Equal(t, entries[0].Path, "missing")
+	assert.NotNil(t, entries[0].Err)
+}
+
+func collectParallelErrors(entries <-chan WalkEntry) []WalkEntry {
+	result := []WalkEntry{}
+	for entry := range entries {
+		result = append(result, entry)
+	}
+	return result
+}
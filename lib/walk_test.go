@@ -0,0 +1,183 @@
+package lib
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func walkPaths(t *testing.T, fsys fs.FS, root string, options ...WalkOptions) []string {
+	paths := []string{}
+	err := Walk(fsys, root, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		paths = append(paths, name)
+		return nil
+	}, options...)
+	assert.Nil(t, err)
+	return paths
+}
+
+func testTree() fstest.MapFS {
+	return fstest.MapFS{
+		".gitignore":             {Data: []byte("*.log\nbuild/\n")},
+		".git/config":            {Data: []byte{}},
+		".hidden":                {Data: []byte{}},
+		"main.go":                {Data: []byte{}},
+		"debug.log":              {Data: []byte{}},
+		"build/out.bin":          {Data: []byte{}},
+		"src/app.go":             {Data: []byte{}},
+		"src/.gitignore":         {Data: []byte("!keep.log\ngenerated/\n")},
+		"src/keep.log":           {Data: []byte{}},
+		"src/other.log":          {Data: []byte{}},
+		"src/generated/x.go":     {Data: []byte{}},
+		"src/nested/build/y.bin": {Data: []byte{}},
+	}
+}
+
+func TestWalk(t *testing.T) {
+	assert.Equal(t, walkPaths(t, testTree(), "."), []string{
+		".",
+		"main.go",
+		"src",
+		"src/app.go",
+		"src/keep.log",
+		"src/nested",
+	})
+
+	assert.Equal(t, walkPaths(t, testTree(), ".", WalkOptions{Hidden: true}), []string{
+		".",
+		".gitignore",
+		".hidden",
+		"main.go",
+		"src",
+		"src/.gitignore",
+		"src/app.go",
+		"src/keep.log",
+		"src/nested",
+	})
+
+	// the patterns are relative to the root of the walk
+	assert.Equal(t, walkPaths(t, testTree(), "src"), []string{
+		"src",
+		"src/app.go",
+		"src/keep.log",
+		"src/nested",
+		"src/nested/build",
+		"src/nested/build/y.bin",
+		"src/other.log",
+	})
+
+	assert.Equal(t, walkPaths(t, testTree(), ".", WalkOptions{Matcher: NewMatcher(ParseGitIgnore("*.go\n"))}), []string{
+		".",
+		"src",
+		"src/keep.log",
+		"src/nested",
+	})
+}
+
+func TestWalkPrunesIgnoredDirectories(t *testing.T) {
+	visited := []string{}
+	err := Walk(testTree(), ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		visited = append(visited, name)
+		if name == "src" {
+			return fs.SkipDir
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, visited, []string{".", "main.go", "src"})
+
+	visited = []string{}
+	err = Walk(testTree(), ".", func(name string, entry fs.DirEntry, err error) error {
+		visited = append(visited, name)
+		if name == "main.go" {
+			return fs.SkipAll
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, visited, []string{".", "main.go"})
+}
+
+func TestWalkSymlinks(t *testing.T) {
+	root := t.TempDir()
+	assert.Nil(t, os.MkdirAll(filepath.Join(root, "real", "sub"), 0o755))
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "real", "sub", "file.txt"), []byte{}, 0o644))
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "real", "sub", "file.log"), []byte{}, 0o644))
+	assert.Nil(t, os.WriteFile(filepath.Join(root, ".gitignore"), []byte("*.log\n"), 0o644))
+	if err := os.Symlink(filepath.Join(root, "real"), filepath.Join(root, "link")); err != nil {
+		t.Skip("symbolic links are not supported", err)
+	}
+	// a loop back to the root
+	assert.Nil(t, os.Symlink(root, filepath.Join(root, "real", "loop")))
+
+	fsys := os.DirFS(root)
+	assert.Equal(t, walkPaths(t, fsys, "."), []string{
+		".",
+		"link",
+		"real",
+		"real/loop",
+		"real/sub",
+		"real/sub/file.txt",
+	})
+	assert.Equal(t, walkPaths(t, fsys, ".", WalkOptions{FollowSymlinks: true}), []string{
+		".",
+		"link",
+		"link/loop",
+		"link/sub",
+		"link/sub/file.txt",
+		"real",
+		"real/loop",
+		"real/sub",
+		"real/sub/file.txt",
+	})
+}
+
+func TestIgnoreTreeLastMatchingPattern(t *testing.T) {
+	tree := NewIgnoreTree(testTree(), ".")
+
+	pattern, err := tree.LastMatchingPattern("src/other.log", false)
+	assert.Nil(t, err)
+	assert.Equal(t, pattern.String(), "*.log")
+	assert.Equal(t, pattern.Source, ".gitignore")
+	assert.Equal(t, pattern.Line, 1)
+
+	pattern, err = tree.LastMatchingPattern("src/keep.log", false)
+	assert.Nil(t, err)
+	assert.Equal(t, pattern.String(), "!keep.log")
+	assert.Equal(t, pattern.Source, "src/.gitignore")
+
+	// the parent directory is ignored, and the path does not need to exist
+	pattern, err = tree.LastMatchingPattern("src/generated/missing/keep.log", false)
+	assert.Nil(t, err)
+	assert.Equal(t, pattern.String(), "generated/")
+
+	pattern, err = tree.LastMatchingPattern("main.go", false)
+	assert.Nil(t, err)
+	assert.Nil(t, pattern)
+}
+
+func TestIgnoreTreeIgnoreCase(t *testing.T) {
+	tree := NewIgnoreTree(testTree(), ".", WalkOptions{IgnoreCase: true})
+
+	pattern, err := tree.LastMatchingPattern("SRC/Other.LOG", false)
+	assert.Nil(t, err)
+	assert.Equal(t, pattern.String(), "*.log")
+	// the nested ignore files keep the case sensitivity
+	pattern, err = tree.LastMatchingPattern("src/Generated/x.go", false)
+	assert.Nil(t, err)
+	assert.Equal(t, pattern.String(), "generated/")
+
+	pattern, err = NewIgnoreTree(testTree(), ".").LastMatchingPattern("SRC/Other.LOG", false)
+	assert.Nil(t, err)
+	assert.Nil(t, pattern)
+}
@@ -0,0 +1,325 @@
+package lib
+
+/*
+ * Go port of git's wildmatch <https://github.com/git/git/blob/master/wildmatch.c>
+ *
+ * Written by Rich $alz, mentioned in the BSD-style license of rsync.
+ * Modified by Wayne Davison and the git project.
+ */
+
+/** Flags of `Wildmatch` */
+type WildmatchFlags uint
+
+const (
+	/** `*` and `?` do not match `/`, and `**` matches across directories */
+	WildmatchPathname WildmatchFlags = 1 << iota
+	/** Compare ASCII letters case-insensitively */
+	WildmatchCaseFold
+)
+
+const (
+	wmMatch = iota
+	wmNoMatch
+	wmAbortAll
+	wmAbortToStarStar
+)
+
+/**
+ * Matches the text against a gitignore pattern exactly like git does
+ *
+ * @param {string} pattern The pattern (without the gitignore `!` and trailing `/` markers)
+ * @param {string} text The text to match. Like the paths of git, it cannot contain NUL bytes.
+ * @param {WildmatchFlags} flags The flags of the matching
+ * @returns {bool} true if the pattern matches the whole text
+ */
+func Wildmatch(pattern string, text string, flags WildmatchFlags) bool {
+	return dowild(pattern, 0, text, 0, flags) == wmMatch
+}
+
+/** The byte at the given index, or 0 past the end (like a C string) */
+func charAt(str string, i int) byte {
+	if i < len(str) {
+		return str[i]
+	}
+	return 0
+}
+
+func isGlobSpecial(ch byte) bool {
+	return ch == '*' || ch == '?' || ch == '[' || ch == '\\'
+}
+
+func isUpper(ch byte) bool { return 'A' <= ch && ch <= 'Z' }
+func isLower(ch byte) bool { return 'a' <= ch && ch <= 'z' }
+func isDigit(ch byte) bool { return '0' <= ch && ch <= '9' }
+
+func toLower(ch byte) byte {
+	if isUpper(ch) {
+		return ch + ('a' - 'A')
+	}
+	return ch
+}
+
+func toUpper(ch byte) byte {
+	if isLower(ch) {
+		return ch - ('a' - 'A')
+	}
+	return ch
+}
+
+func dowild(pattern string, p int, text string, t int, flags WildmatchFlags) int {
+	caseFold := flags&WildmatchCaseFold != 0
+	pathname := flags&WildmatchPathname != 0
+
+	for ; charAt(pattern, p) != 0; t, p = t+1, p+1 {
+		pCh := pattern[p]
+		tCh := charAt(text, t)
+		if tCh == 0 && pCh != '*' {
+			return wmAbortAll
+		}
+		if caseFold {
+			tCh = toLower(tCh)
+			pCh = toLower(pCh)
+		}
+		switch pCh {
+		case '\\':
+			// Literal match with the following character
+			p++
+			pCh = charAt(pattern, p)
+			if tCh != pCh {
+				return wmNoMatch
+			}
+		default:
+			if tCh != pCh {
+				return wmNoMatch
+			}
+		case '?':
+			// Match anything but '/'
+			if pathname && tCh == '/' {
+				return wmNoMatch
+			}
+		case '*':
+			matchSlash := false
+			p++
+			if charAt(pattern, p) == '*' {
+				prevP := p - 2
+				for p++; charAt(pattern, p) == '*'; p++ {
+				}
+				if !pathname {
+					// without WildmatchPathname, `**` is the same as `*`
+					matchSlash = true
+				} else if (prevP < 0 || pattern[prevP] == '/') &&
+					(charAt(pattern, p) == 0 || charAt(pattern, p) == '/' ||
+						(charAt(pattern, p) == '\\' && charAt(pattern, p+1) == '/')) {
+					// Assuming we already match `foo/` and are at `**/`, just assume it matches nothing and go
+					// ahead match the rest of the pattern with the remaining string. This makes `foo/**/bar`
+					// match both `foo/bar` and `foo/a/bar`.
+					if charAt(pattern, p) == '/' && dowild(pattern, p+1, text, t, flags) == wmMatch {
+						return wmMatch
+					}
+					matchSlash = true
+				} else {
+					matchSlash = false
+				}
+			} else {
+				// without WildmatchPathname, `*` is the same as `**`
+				matchSlash = !pathname
+			}
+			if charAt(pattern, p) == 0 {
+				// Trailing `**` matches everything. Trailing `*` matches only if there are no more slashes.
+				if !matchSlash && indexByteFrom(text, t, '/') != -1 {
+					return wmNoMatch
+				}
+				return wmMatch
+			} else if !matchSlash && pattern[p] == '/' {
+				// one asterisk followed by a slash matches the next directory
+				slash := indexByteFrom(text, t, '/')
+				if slash == -1 {
+					return wmNoMatch
+				}
+				// the slash is consumed by the loop
+				t = slash
+				continue
+			}
+			for {
+				if tCh == 0 {
+					break
+				}
+				// Advance faster when an asterisk is followed by a literal. If matchSlash is false, do not look
+				// past the first slash as it cannot belong to `*`.
+				if !isGlobSpecial(pattern[p]) {
+					pCh = pattern[p]
+					if caseFold {
+						pCh = toLower(pCh)
+					}
+					for {
+						tCh = charAt(text, t)
+						if tCh == 0 || (!matchSlash && tCh == '/') {
+							break
+						}
+						if caseFold {
+							tCh = toLower(tCh)
+						}
+						if tCh == pCh {
+							break
+						}
+						t++
+					}
+					if tCh != pCh {
+						return wmNoMatch
+					}
+				}
+				matched := dowild(pattern, p, text, t, flags)
+				if matched != wmNoMatch {
+					if !matchSlash || matched != wmAbortToStarStar {
+						return matched
+					}
+				} else if !matchSlash && tCh == '/' {
+					return wmAbortToStarStar
+				}
+				t++
+				tCh = charAt(text, t)
+			}
+			return wmAbortAll
+		case '[':
+			p++
+			pCh = charAt(pattern, p)
+			if pCh == '^' {
+				pCh = '!'
+			}
+			negated := pCh == '!'
+			if negated {
+				// Inverted character class
+				p++
+				pCh = charAt(pattern, p)
+			}
+			var prevCh byte
+			matched := false
+			for {
+				if pCh == 0 {
+					return wmAbortAll
+				}
+				if pCh == '\\' {
+					p++
+					pCh = charAt(pattern, p)
+					if pCh == 0 {
+						return wmAbortAll
+					}
+					if tCh == pCh {
+						matched = true
+					}
+				} else if pCh == '-' && prevCh != 0 && charAt(pattern, p+1) != 0 && charAt(pattern, p+1) != ']' {
+					p++
+					pCh = pattern[p]
+					if pCh == '\\' {
+						p++
+						pCh = charAt(pattern, p)
+						if pCh == 0 {
+							return wmAbortAll
+						}
+					}
+					if tCh <= pCh && tCh >= prevCh {
+						matched = true
+					} else if caseFold && isLower(tCh) {
+						tChUpper := toUpper(tCh)
+						if tChUpper <= pCh && tChUpper >= prevCh {
+							matched = true
+						}
+					}
+					// This makes prevCh get set to 0
+					pCh = 0
+				} else if pCh == '[' && charAt(pattern, p+1) == ':' {
+					p += 2
+					s := p
+					for charAt(pattern, p) != 0 && pattern[p] != ']' {
+						p++
+					}
+					if charAt(pattern, p) == 0 {
+						return wmAbortAll
+					}
+					i := p - s - 1
+					if i < 0 || pattern[p-1] != ':' {
+						// Didn't find ":]", so treat like a normal set
+						p = s - 2
+						pCh = '['
+						if tCh == pCh {
+							matched = true
+						}
+						prevCh = pCh
+						p++
+						pCh = charAt(pattern, p)
+						if pCh == ']' {
+							break
+						}
+						continue
+					}
+					isMatch, ok := matchCharClass(pattern[s:s+i], tCh, caseFold)
+					if !ok {
+						// malformed [:class:] string
+						return wmAbortAll
+					}
+					if isMatch {
+						matched = true
+					}
+					pCh = 0
+				} else if tCh == pCh {
+					matched = true
+				}
+				prevCh = pCh
+				p++
+				pCh = charAt(pattern, p)
+				if pCh == ']' {
+					break
+				}
+			}
+			if matched == negated || (pathname && tCh == '/') {
+				return wmNoMatch
+			}
+		}
+	}
+
+	if t < len(text) {
+		return wmNoMatch
+	}
+	return wmMatch
+}
+
+/** Matches a character against a POSIX character class name. The second result is false for unknown classes. */
+func matchCharClass(class string, ch byte, caseFold bool) (bool, bool) {
+	switch class {
+	case "alnum":
+		return isUpper(ch) || isLower(ch) || isDigit(ch), true
+	case "alpha":
+		return isUpper(ch) || isLower(ch), true
+	case "blank":
+		return ch == ' ' || ch == '\t', true
+	case "cntrl":
+		return ch < 0x20 || ch == 0x7f, true
+	case "digit":
+		return isDigit(ch), true
+	case "graph":
+		return 0x21 <= ch && ch <= 0x7e, true
+	case "lower":
+		return isLower(ch), true
+	case "print":
+		return 0x20 <= ch && ch <= 0x7e, true
+	case "punct":
+		return 0x21 <= ch && ch <= 0x7e && !(isUpper(ch) || isLower(ch) || isDigit(ch)), true
+	case "space":
+		return ch == ' ' || ('\t' <= ch && ch <= '\r'), true
+	case "upper":
+		return isUpper(ch) || (caseFold && isLower(ch)), true
+	case "xdigit":
+		return isDigit(ch) || ('a' <= ch && ch <= 'f') || ('A' <= ch && ch <= 'F'), true
+	default:
+		return false, false
+	}
+}
+
+func indexByteFrom(str string, from int, ch byte) int {
+	for i := from; i < len(str); i++ {
+		if str[i] == ch {
+			return i
+		}
+	}
+	return -1
+}
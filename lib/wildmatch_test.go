@@ -0,0 +1,220 @@
+package lib
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWildmatch(t *testing.T) {
+	// cases from git's t3070-wildmatch.sh: {match with WildmatchPathname, match without it, text, pattern}
+	cases := []struct {
+		pathname bool
+		plain    bool
+		text     string
+		pattern  string
+	}{
+		{true, true, "foo", "foo"},
+		{false, false, "foo", "bar"},
+		{true, true, "", ""},
+		{true, true, "foo", "???"},
+		{false, false, "foo", "??"},
+		{true, true, "foo", "*"},
+		{true, true, "foo", "f*"},
+		{false, false, "foo", "*f"},
+		{true, true, "foo", "*foo*"},
+		{true, true, "foobar", "*ob*a*r*"},
+		{true, true, "aaaaaaabababab", "*ab"},
+		{true, true, "foo*", "foo\\*"},
+		{false, false, "foobar", "foo\\*bar"},
+		{true, true, "f\\oo", "f\\\\oo"},
+		{true, true, "ball", "*[al]?"},
+		{false, false, "ten", "[ten]"},
+		{true, true, "ten", "**[!te]"},
+		{false, false, "ten", "**[!ten]"},
+		{true, true, "ten", "t[a-g]n"},
+		{false, false, "ten", "t[!a-g]n"},
+		{true, true, "ton", "t[!a-g]n"},
+		{true, true, "ton", "t[^a-g]n"},
+		{true, true, "a]b", "a[]]b"},
+		{true, true, "a-b", "a[]-]b"},
+		{true, true, "a]b", "a[]-]b"},
+		{false, false, "aab", "a[]-]b"},
+		{true, true, "aab", "a[]a-]b"},
+		{true, true, "]", "]"},
+		{false, true, "foo/baz/bar", "foo*bar"},
+		{false, true, "foo/baz/bar", "foo**bar"},
+		{true, true, "foobazbar", "foo**bar"},
+		{true, true, "foo/baz/bar", "foo/**/bar"},
+		{true, false, "foo/baz/bar", "foo/**/**/bar"},
+		{true, true, "foo/b/a/z/bar", "foo/**/bar"},
+		{true, false, "foo/bar", "foo/**/bar"},
+		{false, true, "foo/bar", "foo?bar"},
+		{false, true, "foo/bar", "foo[/]bar"},
+		{false, true, "foo/bar", "f[^eiu][^eiu][^eiu][^eiu][^eiu]r"},
+		{true, true, "foo-bar", "f[^eiu][^eiu][^eiu][^eiu][^eiu]r"},
+		{true, false, "foo", "**/foo"},
+		{true, true, "XXX/foo", "**/foo"},
+		{true, true, "bar/baz/foo", "**/foo"},
+		{false, true, "bar/baz/foo", "*/foo"},
+		{false, true, "foo/bar/baz", "**/bar*"},
+		{true, true, "deep/foo/bar/baz", "**/bar/*"},
+		{false, true, "deep/foo/bar/baz/", "**/bar/*"},
+		{true, true, "deep/foo/bar/baz/", "**/bar/**"},
+		{false, false, "deep/foo/bar", "**/bar/*"},
+		{true, true, "deep/foo/bar/", "**/bar/**"},
+		{false, true, "foo/bar/baz", "**/bar**"},
+		{true, true, "foo/bar/baz/x", "*/bar/**"},
+		{false, true, "deep/foo/bar/baz/x", "*/bar/**"},
+		{true, true, "deep/foo/bar/baz/x", "**/bar/*/*"},
+		{false, false, "acrt", "a[c-c]st"},
+		{true, true, "acrt", "a[c-c]rt"},
+		{false, false, "]", "[!]-]"},
+		{true, true, "a", "[!]-]"},
+		{false, false, "", "\\"},
+		{false, false, "\\", "\\"},
+		{false, false, "XXX/\\", "*/\\"},
+		{true, true, "XXX/\\", "*/\\\\"},
+		{true, true, "foo", "foo"},
+		{true, true, "@foo", "@foo"},
+		{false, false, "foo", "@foo"},
+		{true, true, "[ab]", "\\[ab]"},
+		{true, true, "[ab]", "[[]ab]"},
+		{true, true, "[ab]", "[[:]ab]"},
+		{false, false, "[ab]", "[[::]ab]"},
+		{true, true, "[ab]", "[[:digit]ab]"},
+		{true, true, "[ab]", "[\\[:]ab]"},
+		{true, true, "?a?b", "\\??\\?b"},
+		{true, true, "abc", "\\a\\b\\c"},
+		{false, false, "foo", ""},
+		{true, true, "foo/bar/baz/to", "**/t[o]"},
+		{true, true, "a1B", "[[:alpha:]][[:digit:]][[:upper:]]"},
+		{false, false, "a", "[[:digit:][:upper:][:space:]]"},
+		{true, true, "A", "[[:digit:][:upper:][:space:]]"},
+		{true, true, "1", "[[:digit:][:upper:][:space:]]"},
+		{false, false, "1", "[[:digit:][:upper:][:spaci:]]"},
+		{true, true, " ", "[[:digit:][:upper:][:space:]]"},
+		{false, false, ".", "[[:digit:][:upper:][:space:]]"},
+		{true, true, ".", "[[:digit:][:punct:][:space:]]"},
+		{true, true, "5", "[[:xdigit:]]"},
+		{true, true, "f", "[[:xdigit:]]"},
+		{true, true, "D", "[[:xdigit:]]"},
+		{true, true, "_", "[[:alnum:][:alpha:][:blank:][:cntrl:][:digit:][:graph:][:lower:][:print:][:punct:][:space:][:upper:][:xdigit:]]"},
+		{true, true, "5", "[a-c[:digit:]x-z]"},
+		{true, true, "b", "[a-c[:digit:]x-z]"},
+		{true, true, "y", "[a-c[:digit:]x-z]"},
+		{false, false, "q", "[a-c[:digit:]x-z]"},
+		{true, true, "]", "[\\\\-^]"},
+		{false, false, "[", "[\\\\-^]"},
+		{true, true, "-", "[\\-_]"},
+		{true, true, "]", "[\\]]"},
+		{false, false, "\\]", "[\\]]"},
+		{false, false, "\\", "[\\]]"},
+		{false, false, "ab", "a[]b"},
+		{false, false, "a[]b", "a[]b"},
+		{false, false, "ab[", "ab["},
+		{false, false, "ab", "[!"},
+		{false, false, "ab", "[-"},
+		{true, true, "-", "[-]"},
+		{false, false, "-", "[a-"},
+		{false, false, "-", "[!a-"},
+		{true, true, "-", "[--A]"},
+		{true, true, "5", "[--A]"},
+		{true, true, " ", "[ --]"},
+		{true, true, "$", "[ --]"},
+		{true, true, "-", "[ --]"},
+		{false, false, "0", "[ --]"},
+		{true, true, "-", "[---]"},
+		{true, true, "-", "[------]"},
+		{false, false, "j", "[a-e-n]"},
+		{true, true, "-", "[a-e-n]"},
+		{true, true, "a", "[!------]"},
+		{false, false, "[", "[]-a]"},
+		{true, true, "^", "[]-a]"},
+		{false, false, "^", "[!]-a]"},
+		{true, true, "[", "[!]-a]"},
+		{true, true, "^", "[a^bc]"},
+		{true, true, "-b]", "[a-]b]"},
+		{false, false, "\\", "[\\]"},
+		{true, true, "\\", "[\\\\]"},
+		{false, false, "\\", "[!\\\\]"},
+		{true, true, "G", "[A-\\\\]"},
+		{false, false, "aaabbb", "b*a"},
+		{false, false, "aabcaa", "*ba*"},
+		{true, true, ",", "[,]"},
+		{true, true, ",", "[\\\\,]"},
+		{true, true, "\\", "[\\\\,]"},
+		{true, true, "-", "[,-.]"},
+		{false, false, "+", "[,-.]"},
+		{false, false, "-.]", "[,-.]"},
+		{true, true, "2", "[\\1-\\3]"},
+		{true, true, "3", "[\\1-\\3]"},
+		{false, false, "4", "[\\1-\\3]"},
+		{true, true, "[", "[[-\\]]"},
+		{true, true, "[", "[[-\\]]"},
+		{true, true, "\\", "[[-\\]]"},
+		{true, true, "]", "[[-\\]]"},
+		{false, false, "-", "[[-\\]]"},
+		{true, true, "-adobe-courier-bold-o-normal--12-120-75-75-m-70-iso8859-1", "-*-*-*-*-*-*-12-*-*-*-m-*-*-*"},
+		{false, false, "-adobe-courier-bold-o-normal--12-120-75-75-X-70-iso8859-1", "-*-*-*-*-*-*-12-*-*-*-m-*-*-*"},
+		{false, false, "-adobe-courier-bold-o-normal--12-120-75-75-/-70-iso8859-1", "-*-*-*-*-*-*-12-*-*-*-m-*-*-*"},
+		{true, true, "XXX/adobe/courier/bold/o/normal//12/120/75/75/m/70/iso8859/1", "XXX/*/*/*/*/*/*/12/*/*/*/m/*/*/*"},
+		{false, false, "XXX/adobe/courier/bold/o/normal//12/120/75/75/X/70/iso8859/1", "XXX/*/*/*/*/*/*/12/*/*/*/m/*/*/*"},
+		{true, true, "abcd/abcdefg/abcdefghijk/abcdefghijklmnop.txt", "**/*a*b*g*n*t"},
+		{false, false, "abcd/abcdefg/abcdefghijk/abcdefghijklmnop.txtz", "**/*a*b*g*n*t"},
+		{false, false, "foo", "*/*/*"},
+		{false, false, "foo/bar", "*/*/*"},
+		{true, true, "foo/bba/arr", "*/*/*"},
+		{false, true, "foo/bb/aa/rr", "*/*/*"},
+		{true, true, "foo/bb/aa/rr", "**/**/**"},
+		{true, true, "abcXdefXghi", "*X*i"},
+		{false, true, "ab/cXd/efXg/hi", "*X*i"},
+		{true, true, "ab/cXd/efXg/hi", "*/*X*/*/*i"},
+		{true, true, "ab/cXd/efXg/hi", "**/*X*/**/*i"},
+	}
+
+	for _, testCase := range cases {
+		assert.Equal(t, testCase.pathname, Wildmatch(testCase.pattern, testCase.text, WildmatchPathname), "%q %q with WildmatchPathname", testCase.pattern, testCase.text)
+		assert.Equal(t, testCase.plain, Wildmatch(testCase.pattern, testCase.text, 0), "%q %q", testCase.pattern, testCase.text)
+	}
+}
+
+func TestWildmatchCaseFold(t *testing.T) {
+	assert.Equal(t, Wildmatch("a", "A", 0), false)
+	assert.Equal(t, Wildmatch("a", "A", WildmatchCaseFold), true)
+	assert.Equal(t, Wildmatch("A", "a", WildmatchCaseFold), true)
+	assert.Equal(t, Wildmatch("[A-Z]", "a", WildmatchCaseFold), true)
+	assert.Equal(t, Wildmatch("[a-z]", "A", WildmatchCaseFold), true)
+	assert.Equal(t, Wildmatch("[[:upper:]]", "a", WildmatchCaseFold), true)
+	assert.Equal(t, Wildmatch("[[:upper:]]", "a", 0), false)
+	assert.Equal(t, Wildmatch("*.JS", "dir/file.js", WildmatchCaseFold), true)
+}
+
+func FuzzWildmatch(f *testing.F) {
+	f.Add("**/*.js", "a/b.js", true)
+	f.Add("[[:alpha:]-z]", "-", false)
+	f.Add("a\\", "a", false)
+	f.Add("[!]-]*", "x", true)
+	f.Fuzz(func(t *testing.T, pattern string, text string, pathname bool) {
+		flags := WildmatchFlags(0)
+		if pathname {
+			flags = WildmatchPathname
+		}
+		Wildmatch(pattern, text, flags)
+		Wildmatch(pattern, text, flags|WildmatchCaseFold)
+		if strings.IndexByte(text, 0) != -1 {
+			// the paths cannot have NUL bytes
+			return
+		}
+
+		// an escaped text matches itself
+		literal := escapePattern(text)
+		if !Wildmatch(literal, text, flags) {
+			t.Fatalf("%q does not match %q", literal, text)
+		}
+		if !pathname && !Wildmatch("*"+literal, text, flags) {
+			t.Fatalf("%q does not match %q", "*"+literal, text)
+		}
+	})
+}
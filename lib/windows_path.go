@@ -0,0 +1,169 @@
+package lib
+
+import (
+	"strings"
+)
+
+/** The kinds of the Windows paths */
+type WindowsPathKind uint
+
+const (
+	/** A relative path (e.g. `dir\file`) */
+	WindowsPathRelative WindowsPathKind = iota
+	/** A path relative to the root of the current drive (e.g. `\dir\file`) */
+	WindowsPathRooted
+	/** A path on a drive (e.g. `C:\dir\file`, or `C:file` that is relative to the current directory of the drive) */
+	WindowsPathDrive
+	/** A path on a network share (e.g. `\\server\share\file`) */
+	WindowsPathUNC
+	/** An extended-length path that is not normalized by Windows (e.g. `\\?\C:\file` or `\\?\UNC\server\share\file`) */
+	WindowsPathExtended
+	/** A path in the device namespace (e.g. `\\.\COM1` or `\\.\pipe\name`) */
+	WindowsPathDevice
+)
+
+/**
+ * A Windows path split into its volume and the rest. Both `\` and `/` are separators. The functions of this file only
+ * inspect the strings, so they behave the same on all the platforms.
+ */
+type WindowsPath struct {
+	Kind WindowsPathKind
+	/**
+	 * The drive (`C:`), the share (`\\server\share`), or the prefix of an extended or a device path (e.g. `\\?\C:`,
+	 * `\\?\UNC\server\share` or `\\.\COM1`). Empty for the relative and the rooted paths.
+	 */
+	Volume string
+	/** The path after the volume. It starts with a separator if the path is absolute. */
+	Path string
+}
+
+/**
+ * Splits a Windows path into its volume and the rest
+ *
+ * @param {string} givenPath The path with `\` or `/` separators
+ * @returns {WindowsPath}
+ */
+func ParseWindowsPath(givenPath string) WindowsPath {
+	if isDriveLetter(givenPath) {
+		return WindowsPath{Kind: WindowsPathDrive, Volume: givenPath[:2], Path: givenPath[2:]}
+	}
+	if len(givenPath) < 2 || !isPathSeparator(givenPath[0]) || !isPathSeparator(givenPath[1]) {
+		if givenPath != "" && isPathSeparator(givenPath[0]) {
+			return WindowsPath{Kind: WindowsPathRooted, Path: givenPath}
+		}
+		return WindowsPath{Kind: WindowsPathRelative, Path: givenPath}
+	}
+
+	// `\\?\` and `\\.\` prefixes
+	if len(givenPath) >= 4 && (givenPath[2] == '?' || givenPath[2] == '.') && isPathSeparator(givenPath[3]) {
+		kind := WindowsPathExtended
+		if givenPath[2] == '.' {
+			kind = WindowsPathDevice
+		}
+		rest := givenPath[4:]
+		volumeEnd := 4
+		switch {
+		case isDriveLetter(rest):
+			volumeEnd += 2
+		case len(rest) >= 4 && strings.EqualFold(rest[:3], "UNC") && isPathSeparator(rest[3]):
+			volumeEnd += 4 + shareEnd(rest[4:])
+		default:
+			volumeEnd += componentEnd(rest)
+		}
+		return WindowsPath{Kind: kind, Volume: givenPath[:volumeEnd], Path: givenPath[volumeEnd:]}
+	}
+
+	end := shareEnd(givenPath[2:])
+	if end == 0 {
+		// `\\` without a server is rooted
+		return WindowsPath{Kind: WindowsPathRooted, Path: givenPath}
+	}
+	return WindowsPath{Kind: WindowsPathUNC, Volume: givenPath[:2+end], Path: givenPath[2+end:]}
+}
+
+/** Is the path absolute? The rooted paths (e.g. `\dir`) and the drive-relative paths (e.g. `C:dir`) are not. */
+func (windowsPath WindowsPath) IsAbs() bool {
+	switch windowsPath.Kind {
+	case WindowsPathUNC, WindowsPathExtended, WindowsPathDevice:
+		return true
+	case WindowsPathDrive:
+		return windowsPath.Path != "" && isPathSeparator(windowsPath.Path[0])
+	default:
+		return false
+	}
+}
+
+/**
+ * The volume with `/` separators. The extended prefix is removed if the path has a drive or a share (e.g. `\\?\C:` is
+ * `C:`, and `\\?\UNC\server\share` is `//server/share`).
+ */
+func (windowsPath WindowsPath) PosixVolume() string {
+	volume := windowsPath.Volume
+	if windowsPath.Kind == WindowsPathExtended {
+		rest := volume[4:]
+		switch {
+		case isDriveLetter(rest):
+			volume = rest
+		case len(rest) >= 4 && strings.EqualFold(rest[:3], "UNC"):
+			volume = `\\` + rest[4:]
+		}
+	}
+	return strings.ReplaceAll(volume, "\\", "/")
+}
+
+/**
+ * Is the file name reserved for a device on Windows (e.g. `CON`, `nul.txt` or `COM1`)? Like Windows, the extension and
+ * the trailing spaces are ignored.
+ *
+ * @param {string} name A file name without separators
+ * @returns {bool}
+ */
+func IsReservedWindowsName(name string) bool {
+	if dot := strings.IndexByte(name, '.'); dot != -1 {
+		name = name[:dot]
+	}
+	name = strings.ToUpper(strings.TrimRight(name, " "))
+	switch name {
+	case "CON", "PRN", "AUX", "NUL", "CONIN$", "CONOUT$":
+		return true
+	}
+	if len(name) < 4 || (name[:3] != "COM" && name[:3] != "LPT") {
+		return false
+	}
+	switch number := name[3:]; number {
+	case "1", "2", "3", "4", "5", "6", "7", "8", "9", "¹", "²", "³":
+		return true
+	}
+	return false
+}
+
+func isPathSeparator(ch byte) bool {
+	return ch == '\\' || ch == '/'
+}
+
+/** Does the path start with a drive (e.g. `C:`)? */
+func isDriveLetter(givenPath string) bool {
+	return len(givenPath) >= 2 && givenPath[1] == ':' && (isUpper(givenPath[0]) || isLower(givenPath[0]))
+}
+
+/** The length of the first component of the path */
+func componentEnd(givenPath string) int {
+	end := strings.IndexAny(givenPath, `\/`)
+	if end == -1 {
+		return len(givenPath)
+	}
+	return end
+}
+
+/** The length of `server\share` at the start of the path, or 0 if the path has no server or no share */
+func shareEnd(givenPath string) int {
+	server := componentEnd(givenPath)
+	if server == 0 || server == len(givenPath) {
+		return 0
+	}
+	share := componentEnd(givenPath[server+1:])
+	if share == 0 {
+		return 0
+	}
+	return server + 1 + share
+}
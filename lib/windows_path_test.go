@@ -0,0 +1,48 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWindowsPath(t *testing.T) {
+	cases := []struct {
+		path     string
+		expected WindowsPath
+		isAbs    bool
+		volume   string
+	}{
+		{`dir\file`, WindowsPath{WindowsPathRelative, ``, `dir\file`}, false, ``},
+		{``, WindowsPath{WindowsPathRelative, ``, ``}, false, ``},
+		{`\dir`, WindowsPath{WindowsPathRooted, ``, `\dir`}, false, ``},
+		{`/dir`, WindowsPath{WindowsPathRooted, ``, `/dir`}, false, ``},
+		{`C:\dir\file`, WindowsPath{WindowsPathDrive, `C:`, `\dir\file`}, true, `C:`},
+		{`c:/dir`, WindowsPath{WindowsPathDrive, `c:`, `/dir`}, true, `c:`},
+		{`C:file`, WindowsPath{WindowsPathDrive, `C:`, `file`}, false, `C:`},
+		{`C:`, WindowsPath{WindowsPathDrive, `C:`, ``}, false, `C:`},
+		{`\\server\share\dir`, WindowsPath{WindowsPathUNC, `\\server\share`, `\dir`}, true, `//server/share`},
+		{`//server/share`, WindowsPath{WindowsPathUNC, `//server/share`, ``}, true, `//server/share`},
+		{`\\server`, WindowsPath{WindowsPathRooted, ``, `\\server`}, false, ``},
+		{`\\?\C:\dir`, WindowsPath{WindowsPathExtended, `\\?\C:`, `\dir`}, true, `C:`},
+		{`\\?\UNC\server\share\dir`, WindowsPath{WindowsPathExtended, `\\?\UNC\server\share`, `\dir`}, true, `//server/share`},
+		{`\\?\Volume{b75e2c83}\dir`, WindowsPath{WindowsPathExtended, `\\?\Volume{b75e2c83}`, `\dir`}, true, `//?/Volume{b75e2c83}`},
+		{`\\.\COM1`, WindowsPath{WindowsPathDevice, `\\.\COM1`, ``}, true, `//./COM1`},
+		{`\\.\pipe\name`, WindowsPath{WindowsPathDevice, `\\.\pipe`, `\name`}, true, `//./pipe`},
+	}
+	for _, testCase := range cases {
+		windowsPath := ParseWindowsPath(testCase.path)
+		assert.Equal(t, windowsPath, testCase.expected, testCase.path)
+		assert.Equal(t, windowsPath.IsAbs(), testCase.isAbs, testCase.path)
+		assert.Equal(t, windowsPath.PosixVolume(), testCase.volume, testCase.path)
+	}
+}
+
+func TestIsReservedWindowsName(t *testing.T) {
+	for _, name := range []string{"CON", "nul", "Aux.txt", "COM1", "lpt9.tar.gz", "PRN ", "COM¹", "CONIN$"} {
+		assert.True(t, IsReservedWindowsName(name), name)
+	}
+	for _, name := range []string{"", "CONSOLE", "COM", "COM10", "LPT0x", "nul_", "a.nul"} {
+		assert.False(t, IsReservedWindowsName(name), name)
+	}
+}